@@ -0,0 +1,308 @@
+package hummus
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aditya87/hummus/tree"
+)
+
+// maxPathIndex is the largest array index Check accepts in a path, since
+// Marshal pads arrays with null up to every index that is set
+const maxPathIndex = 10000
+
+var (
+	pathSegmentRegex = regexp.MustCompile(`^[^\[\]]+(\[\d+\])*$`)
+	pathIndexRegex   = regexp.MustCompile(`\[(\d+)\]`)
+)
+
+// CheckError lists every problem Check found
+type CheckError struct {
+	Problems []error
+}
+
+func (e *CheckError) Error() string {
+	var messages []string
+	for _, problem := range e.Problems {
+		messages = append(messages, problem.Error())
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+type checkedField struct {
+	name string
+	path string
+}
+
+type checker struct {
+	problems []error
+	checked  map[reflect.Type]bool
+	tags     Tags
+}
+
+// Check validates the hummus tags of the given structs (or their reflect.Types)
+// without marshaling anything, so that it can be run from an init function or
+// a test. It reports every invalid tag, path that is set twice or conflicts with
+// another, array index that nothing sets or is above 10000 and field that can't
+// be marshaled, in a *CheckError.
+func Check(types ...interface{}) error {
+	return Tags{}.Check(types...)
+}
+
+// Check is like the package-level Check, but checks the chosen tags
+func (tags Tags) Check(types ...interface{}) error {
+	c := &checker{checked: make(map[reflect.Type]bool), tags: tags}
+
+	for _, typ := range types {
+		t, ok := typ.(reflect.Type)
+		if !ok {
+			t = reflect.TypeOf(typ)
+		}
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if t == nil || t.Kind() != reflect.Struct {
+			c.addf("error: can only check structs, got %v", t)
+			continue
+		}
+
+		c.checkStruct(t)
+	}
+
+	if len(c.problems) == 0 {
+		return nil
+	}
+
+	return &CheckError{Problems: c.problems}
+}
+
+func (c *checker) addf(format string, args ...interface{}) {
+	c.problems = append(c.problems, fmt.Errorf(format, args...))
+}
+
+// checkStruct checks the document that Marshal produces from a type
+func (c *checker) checkStruct(t reflect.Type) {
+	if c.checked[t] {
+		return
+	}
+	c.checked[t] = true
+
+	name := t.Name()
+	if name == "" {
+		name = t.String()
+	}
+
+	layout := tree.NewTree()
+	var fields []checkedField
+	c.checkFields(layout, &fields, t, "", name+".")
+
+	c.checkGaps(name, layout.BuildJSON().Data(), "")
+}
+
+func (c *checker) checkFields(layout tree.Tree, fields *[]checkedField, t reflect.Type, prefix, fieldPrefix string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := fieldPrefix + field.Name
+
+		tag, ht, err := c.tags.parseField(field)
+		if tag.Get("hummus") == "" {
+			if field.PkgPath != "" {
+				c.addf("error: field %s is unexported and has no hummus tag", name)
+			}
+			continue
+		} else if err != nil {
+			c.addf("error: field %s: %s", name, errorText(err))
+			continue
+		}
+
+		if err := checkPath(ht.tagName); err != nil {
+			c.addf("error: field %s: %s", name, errorText(err))
+			continue
+		}
+
+		if ht.isComputed() {
+			if err := checkComputed(ht, field, t); err != nil {
+				c.addf("error: field %s: %s", name, errorText(err))
+			}
+		} else if field.PkgPath != "" {
+			c.addf("error: field %s is unexported", name)
+			continue
+		}
+
+		if ht.asString && !quotable(field.Type) {
+			c.addf("error: field %s: the string option only applies to numbers and booleans", name)
+		}
+
+		if ht.hasDefault {
+			if _, err := defaultValue(field, ht.defaultValue); err != nil {
+				c.addf("error: field %s: %s", name, errorText(err))
+			}
+		}
+
+		path := ht.tagName
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		if err := checkFormat(ht, field.Type); err != nil {
+			c.addf("error: field %s: %s", name, errorText(err))
+		}
+
+		kind := ht.kind(field.Type)
+		if kind == nestedField && c.hasHiddenFields(field.Type) {
+			c.addf("error: field %s has unsupported type %s: it has unexported fields without hummus tags", name, field.Type)
+			continue
+		} else if kind == nestedField {
+			c.checkFields(layout, fields, field.Type, path, name+".")
+			continue
+		}
+
+		c.checkKind(name, field.Type, map[reflect.Type]bool{})
+
+		if kind == structSliceField {
+			c.checkStruct(field.Type.Elem())
+		}
+
+		if other, ok := conflictingField(*fields, path); ok {
+			if other.path == path {
+				c.addf("error: fields %s and %s both set path %s", other.name, name, path)
+			} else {
+				c.addf("error: path %s of field %s conflicts with path %s of field %s", path, name, other.path, other.name)
+			}
+			continue
+		}
+
+		*fields = append(*fields, checkedField{name: name, path: path})
+		layout.Insert(fmt.Sprintf("hummus:%q", path), &checkedField{name: name, path: path}, false)
+	}
+}
+
+// hasHiddenFields reports whether t has unexported fields without hummus tags,
+// which Marshal can't read, e.g. time.Time
+func (c *checker) hasHiddenFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath != "" && c.tags.fieldTag(t.Field(i)).Get("hummus") == "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// conflictingField returns the first of fields that can't be set in the same
+// document as path
+func conflictingField(fields []checkedField, path string) (checkedField, bool) {
+	for _, other := range fields {
+		pair := tree.NewTree()
+		pair.Insert(fmt.Sprintf("hummus:%q", other.path), &other, false)
+		if err := pair.Insert(fmt.Sprintf("hummus:%q", path), &checkedField{}, false); err != nil {
+			return other, true
+		}
+	}
+
+	return checkedField{}, false
+}
+
+// checkKind reports the parts of t that encoding/json can't marshal
+func (c *checker) checkKind(name string, t reflect.Type, seen map[reflect.Type]bool) {
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		c.addf("error: field %s has unsupported type %s", name, t)
+	case reflect.Slice, reflect.Array, reflect.Ptr:
+		c.checkKind(name, t.Elem(), seen)
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			c.addf("error: field %s has unsupported map key type %s", name, t.Key())
+		}
+		c.checkKind(name, t.Elem(), seen)
+	case reflect.Struct:
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" && t.Field(i).Tag.Get("json") != "-" {
+				c.checkKind(name, t.Field(i).Type, seen)
+			}
+		}
+	}
+}
+
+// checkGaps reports array elements of the document that no field sets
+func (c *checker) checkGaps(name string, doc interface{}, path string) {
+	switch value := doc.(type) {
+	case map[string]interface{}:
+		var keys []string
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			member := value[key]
+			memberPath := strings.Replace(key, ".", "#", -1)
+			if path != "" {
+				memberPath = path + "." + memberPath
+			}
+			c.checkGaps(name, member, memberPath)
+		}
+	case []interface{}:
+		for i := 0; i < len(value); i++ {
+			if value[i] != nil {
+				c.checkGaps(name, value[i], fmt.Sprintf("%s[%d]", path, i))
+				continue
+			}
+
+			// runs of elements are reported at once
+			last := i
+			for last+1 < len(value) && value[last+1] == nil {
+				last++
+			}
+
+			if last == i {
+				c.addf("error: %s: no field sets %s[%d]", name, path, i)
+			} else {
+				c.addf("error: %s: no field sets %s[%d] to %s[%d]", name, path, i, path, last)
+			}
+			i = last
+		}
+	}
+}
+
+// CheckPath validates the syntax of a hummus path, e.g. "brands[0].name"
+func CheckPath(path string) error {
+	return checkPath(path)
+}
+
+// checkPath validates the syntax of a hummus path
+func checkPath(path string) error {
+	if path == "" {
+		return errors.New("error: empty path")
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		if !pathSegmentRegex.MatchString(segment) {
+			return fmt.Errorf("error: invalid path %s", path)
+		}
+	}
+
+	for _, match := range pathIndexRegex.FindAllStringSubmatch(path, -1) {
+		if index, err := strconv.Atoi(match[1]); err != nil || index > maxPathIndex {
+			return fmt.Errorf("error: index %s in path %s is out of range", match[1], path)
+		}
+	}
+
+	return nil
+}
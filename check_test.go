@@ -0,0 +1,125 @@
+package hummus_test
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type CheckStore struct {
+	Name  string `hummus:"name"`
+	Price int    `hummus:"prices[1]"`
+}
+
+type CheckInfo struct {
+	Name      string         `hummus:"brand.name"`
+	Brand     string         `hummus:"brand"`
+	Duplicate string         `hummus:"brand.name,omitempty"`
+	Array     string         `hummus:"brand.flavors[0]"`
+	Object    string         `hummus:"brand.flavors.name"`
+	Stores    []CheckStore   `hummus:"stores"`
+	Invalid   string         `hummus:"invalid,omitempty,blah"`
+	Currency  string         `hummus:"currency,default=USD,requird"`
+	Role      string         `hummus:"role,groups=admin,omitemtpy"`
+	Empty     string         `hummus:"a..b"`
+	Range     string         `hummus:"items[99999999999999999999]"`
+	Channel   chan int       `hummus:"channel"`
+	Complex   map[bool]int   `hummus:"complex"`
+	Nested    []func() error `hummus:"callbacks"`
+	Untagged  string
+}
+
+var _ = Describe("Check", func() {
+	It("accepts valid types", func() {
+		type Store struct {
+			Name string `hummus:"name"`
+		}
+
+		type Info struct {
+			Name    string  `hummus:"brand.name"`
+			Stores  []Store `hummus:"stores"`
+			Escaped bool    `hummus:"properties#name"`
+			Brand0  string  `hummus:"brands[0],omitempty"`
+			Brand1  string  `hummus:"brands[1]"`
+			Matrix  int     `hummus:"matrix[0][0]"`
+		}
+
+		Expect(hummus.Check(Info{}, reflect.TypeOf(Store{}), &Info{})).To(Succeed())
+	})
+
+	It("reports every problem at once", func() {
+		err := hummus.Check(CheckInfo{})
+		Expect(err).To(BeAssignableToTypeOf(&hummus.CheckError{}))
+		Expect(err.(*hummus.CheckError).Problems).To(HaveLen(12))
+		Expect(err.Error()).To(Equal(`error: path brand of field CheckInfo.Brand conflicts with path brand.name of field CheckInfo.Name
+error: fields CheckInfo.Name and CheckInfo.Duplicate both set path brand.name
+error: path brand.flavors.name of field CheckInfo.Object conflicts with path brand.flavors[0] of field CheckInfo.Array
+error: CheckStore: no field sets prices[0]
+error: field CheckInfo.Invalid: unknown struct tag option blah
+error: field CheckInfo.Currency: unknown struct tag option requird
+error: field CheckInfo.Role: unknown struct tag option omitemtpy
+error: field CheckInfo.Empty: invalid path a..b
+error: field CheckInfo.Range: index 99999999999999999999 in path items[99999999999999999999] is out of range
+error: field CheckInfo.Channel has unsupported type chan int
+error: field CheckInfo.Complex has unsupported map key type bool
+error: field CheckInfo.Nested has unsupported type func() error`))
+	})
+
+	It("checks the fields of nested structs", func() {
+		type Brand struct {
+			Name string `hummus:"name"`
+		}
+
+		type Info struct {
+			Brand     Brand  `hummus:"brand"`
+			BrandName string `hummus:"brand.name"`
+		}
+
+		Expect(hummus.Check(Info{})).To(MatchError("error: fields Info.Brand.Name and Info.BrandName both set path brand.name"))
+	})
+
+	It("reports nested structs that Marshal can't read", func() {
+		type Info struct {
+			At      time.Time `hummus:"at"`
+			Created time.Time `hummus:"created,format=rfc3339"`
+			notes   string
+		}
+
+		err := hummus.Check(Info{})
+		Expect(err).To(BeAssignableToTypeOf(&hummus.CheckError{}))
+		Expect(err.(*hummus.CheckError).Problems).To(ConsistOf(
+			MatchError("error: field Info.At has unsupported type time.Time: it has unexported fields without hummus tags"),
+			MatchError("error: field Info.notes is unexported and has no hummus tag"),
+		))
+	})
+
+	It("reports each run of array indices that nothing sets once", func() {
+		type Info struct {
+			First string `hummus:"items[0]"`
+			Last  string `hummus:"items[5000]"`
+			Far   string `hummus:"others[5000000]"`
+		}
+
+		err := hummus.Check(Info{})
+		Expect(err).To(BeAssignableToTypeOf(&hummus.CheckError{}))
+		Expect(err.Error()).To(Equal(`error: field Info.Far: index 5000000 in path others[5000000] is out of range
+error: Info: no field sets items[1] to items[4999]`))
+	})
+
+	Describe("CheckPath", func() {
+		It("validates a single path", func() {
+			Expect(hummus.CheckPath("brands[0].name")).To(Succeed())
+			Expect(hummus.CheckPath("brands[x].name")).To(MatchError("error: invalid path brands[x].name"))
+			Expect(hummus.CheckPath("")).To(MatchError("error: empty path"))
+		})
+	})
+
+	Context("when passed something other than a struct", func() {
+		It("returns an error", func() {
+			Expect(hummus.Check("info")).To(MatchError("error: can only check structs, got string"))
+		})
+	})
+})
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jeffail/gabs"
+)
+
+const usage = `usage: hummus -spec spec.json [-indent n] [-strict] [-check] [file ...]
+
+Reads JSON or NDJSON documents from the given files (or stdin), reshapes each
+one according to the spec and writes the results to stdout, one per line.
+`
+
+func main() {
+	specPath := flag.String("spec", "", "path to the mapping spec")
+	indent := flag.Int("indent", 0, "number of spaces to indent output with")
+	strict := flag.Bool("strict", false, "fail when an input path is missing")
+	check := flag.Bool("check", false, "only validate the spec")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *specPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*specPath, flag.Args(), *indent, *strict, *check); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(specPath string, inputPaths []string, indent int, strict bool, check bool) error {
+	specFile, err := os.Open(specPath)
+	if err != nil {
+		return err
+	}
+	defer specFile.Close()
+
+	spec, err := LoadSpec(specFile)
+	if err != nil {
+		return err
+	}
+
+	if errs := spec.Check(); len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		return fmt.Errorf("error: spec %s has %d problem(s)", specPath, len(errs))
+	}
+
+	if check {
+		return nil
+	}
+
+	if len(inputPaths) == 0 {
+		inputPaths = []string{"-"}
+	}
+
+	for _, inputPath := range inputPaths {
+		err = reshapeFile(spec, inputPath, os.Stdout, indent, strict)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func reshapeFile(spec Spec, inputPath string, w io.Writer, indent int, strict bool) error {
+	var r io.Reader = os.Stdin
+	if inputPath != "-" {
+		f, err := os.Open(inputPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	return Reshape(spec, r, w, indent, strict)
+}
+
+// Reshape reads a stream of JSON documents (a single document or NDJSON) and
+// writes each one, reshaped according to spec, on its own line
+func Reshape(spec Spec, r io.Reader, w io.Writer, indent int, strict bool) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	for {
+		var doc interface{}
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("error: invalid input: %s", err)
+		}
+
+		input, err := gabs.Consume(doc)
+		if err != nil {
+			return err
+		}
+
+		output, err := spec.Apply(input, strict)
+		if err != nil {
+			return err
+		}
+
+		out := output.String()
+		if indent > 0 {
+			out = output.StringIndent("", strings.Repeat(" ", indent))
+		}
+
+		if _, err = fmt.Fprintln(w, out); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aditya87/hummus"
+	"github.com/aditya87/hummus/tree"
+	"github.com/jeffail/gabs"
+)
+
+// Spec maps output hummus paths (optionally followed by ",omitempty") to
+// hummus paths in the input document, e.g. "brands[0].name" (with literal dots
+// in keys escaped as #).
+type Spec map[string]string
+
+func LoadSpec(r io.Reader) (Spec, error) {
+	var spec Spec
+	if err := json.NewDecoder(r).Decode(&spec); err != nil {
+		return nil, fmt.Errorf("error: invalid spec: %s", err)
+	}
+
+	return spec, nil
+}
+
+func (s Spec) Check() []error {
+	var errs []error
+	scratchTree := tree.NewTree()
+
+	for _, outPath := range s.outPaths() {
+		if strings.TrimSpace(outPath) == "" {
+			errs = append(errs, fmt.Errorf("error: empty output path"))
+			continue
+		}
+
+		if s[outPath] == "" {
+			errs = append(errs, fmt.Errorf("error: empty input path for %s", outPath))
+		} else if err := hummus.CheckPath(s[outPath]); err != nil {
+			errs = append(errs, fmt.Errorf("%s (input path for %s)", err, outPath))
+		}
+
+		// every path gets a value of its own, so that none of them gives way
+		err := scratchTree.Insert(fmt.Sprintf("hummus:%q", outPath), outPath, false)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s)", err, outPath))
+		}
+	}
+
+	return errs
+}
+
+func (s Spec) Apply(input *gabs.Container, strict bool) (*gabs.Container, error) {
+	parseTree := tree.NewTree()
+
+	for _, outPath := range s.outPaths() {
+		inPath := s[outPath]
+		value, found := hummus.Lookup(input.Data(), inPath)
+		if !found {
+			if strict {
+				return nil, fmt.Errorf("error: input path %s not found", inPath)
+			}
+			continue
+		}
+
+		err := parseTree.Insert(fmt.Sprintf("hummus:%q", outPath), value, isEmptyValue(value))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return parseTree.BuildJSON(), nil
+}
+
+// outPaths returns the output paths sorted, so that documents are reshaped
+// the same way on every run
+func (s Spec) outPaths() []string {
+	var paths []string
+	for p := range s {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !value
+	case string:
+		return value == ""
+	case json.Number:
+		f, err := value.Float64()
+		return err == nil && f == 0
+	case float64:
+		return value == 0
+	case []interface{}:
+		return len(value) == 0
+	case map[string]interface{}:
+		return len(value) == 0
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Spec", func() {
+	var spec Spec
+
+	BeforeEach(func() {
+		var err error
+		spec, err = LoadSpec(strings.NewReader(`{
+			"name": "name",
+			"type": "flavor",
+			"suppliers[0].name": "main_supplier_name",
+			"suppliers[1].name": "backup_supplier_name",
+			"suppliers[1].location,omitempty": "backup_supplier_location"
+		}`))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("LoadSpec", func() {
+		Context("when the spec is not a JSON object of strings", func() {
+			It("returns an error", func() {
+				_, err := LoadSpec(strings.NewReader(`{"name": 1}`))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Check", func() {
+		It("accepts a valid spec", func() {
+			Expect(spec.Check()).To(BeEmpty())
+		})
+
+		It("reports every invalid output path", func() {
+			spec = Spec{
+				"name,omitempty,blah": "name",
+				"":                    "flavor",
+				"type":                "",
+			}
+
+			Expect(spec.Check()).To(HaveLen(3))
+		})
+
+		It("reports input paths that aren't hummus paths", func() {
+			spec = Spec{
+				"name":  "brands[x].name",
+				"type":  "brands[0]].name",
+				"price": "brands[0].price",
+			}
+
+			errs := spec.Check()
+			Expect(errs).To(HaveLen(2))
+			Expect(errs[0]).To(MatchError("error: invalid path brands[x].name (input path for name)"))
+			Expect(errs[1]).To(MatchError("error: invalid path brands[0]].name (input path for type)"))
+		})
+
+		It("reports output paths that conflict with each other", func() {
+			spec = Spec{
+				"a":            "x",
+				"a.b":          "y",
+				"items[0]":     "x",
+				"items[0].sku": "y",
+			}
+
+			errs := spec.Check()
+			Expect(errs).To(HaveLen(2))
+			Expect(errs[0]).To(MatchError("error: conflicting values for path a.b (a.b)"))
+			Expect(errs[1]).To(MatchError("error: conflicting values for path items[0].sku (items[0].sku)"))
+		})
+	})
+
+	Describe("Reshape", func() {
+		It("reshapes a single document", func() {
+			var out bytes.Buffer
+			err := Reshape(spec, strings.NewReader(`{
+				"name": "sabra",
+				"flavor": "jalapeno",
+				"main_supplier_name": "Hipster Foods",
+				"backup_supplier_name": "Good Foods",
+				"backup_supplier_location": ""
+			}`), &out, 0, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.Bytes()).To(MatchJSON(`{
+				"name": "sabra",
+				"type": "jalapeno",
+				"suppliers": [
+					{
+						"name": "Hipster Foods"
+					},
+					{
+						"name": "Good Foods"
+					}
+				]
+			}`))
+		})
+
+		It("reshapes every document of an NDJSON stream", func() {
+			var out bytes.Buffer
+			err := Reshape(spec, strings.NewReader(
+				`{"name": "sabra", "flavor": "jalapeno"}`+"\n"+
+					`{"name": "cedars", "flavor": "garlic"}`+"\n",
+			), &out, 0, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			Expect(lines).To(HaveLen(2))
+			Expect([]byte(lines[0])).To(MatchJSON(`{"name": "sabra", "type": "jalapeno"}`))
+			Expect([]byte(lines[1])).To(MatchJSON(`{"name": "cedars", "type": "garlic"}`))
+		})
+
+		It("reads input paths with hummus path syntax", func() {
+			var out bytes.Buffer
+			err := Reshape(Spec{
+				"first":   "brands[0].name",
+				"dotted":  "a#b",
+				"nested":  "brands.name",
+				"missing": "brands[2].name",
+			}, strings.NewReader(`{
+				"brands": [{"name": "sabra"}, {"name": "cedars"}],
+				"a.b": "dotted"
+			}`), &out, 0, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.Bytes()).To(MatchJSON(`{"first": "sabra", "dotted": "dotted"}`))
+		})
+
+		It("indents the output", func() {
+			var out bytes.Buffer
+			err := Reshape(Spec{"name": "name"}, strings.NewReader(`{"name": "sabra"}`), &out, 2, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.String()).To(Equal("{\n  \"name\": \"sabra\"\n}\n"))
+		})
+
+		It("keeps numbers as they were written", func() {
+			var out bytes.Buffer
+			err := Reshape(Spec{"order.id": "id"}, strings.NewReader(`{"id": 12345678901234567890}`), &out, 0, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.String()).To(Equal(`{"order":{"id":12345678901234567890}}` + "\n"))
+		})
+
+		Context("when an input path is missing", func() {
+			It("skips it", func() {
+				var out bytes.Buffer
+				err := Reshape(spec, strings.NewReader(`{"name": "sabra"}`), &out, 0, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(out.Bytes()).To(MatchJSON(`{"name": "sabra"}`))
+			})
+
+			Context("in strict mode", func() {
+				It("returns an error", func() {
+					var out bytes.Buffer
+					err := Reshape(spec, strings.NewReader(`{"name": "sabra"}`), &out, 0, true)
+					Expect(err).To(MatchError("error: input path main_supplier_name not found"))
+				})
+			})
+		})
+
+		Context("when the input is not valid JSON", func() {
+			It("returns an error", func() {
+				var out bytes.Buffer
+				err := Reshape(spec, strings.NewReader(`{"name": `), &out, 0, false)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ExplainProgram generates a program that prints hummus.Explain for the named
+// type of the package at importPath. Go can't load types at run time, so
+// hummusgen explain builds and runs it from inside the package's module.
+func ExplainProgram(importPath, typeName string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `package main
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/aditya87/hummus"
+	explained %q
+)
+
+func main() {
+	fmt.Print(hummus.Explain(reflect.TypeOf((*explained.%s)(nil)).Elem()))
+}
+`, importPath, typeName)
+
+	return buf.Bytes()
+}
+
+// runExplain prints the explanation of typeName from the package in dir
+func runExplain(dir, typeName string, stdout, stderr io.Writer) error {
+	list := exec.Command("go", "list", "-f", "{{.ImportPath}}", ".")
+	list.Dir = dir
+	list.Stderr = stderr
+	out, err := list.Output()
+	if err != nil {
+		return fmt.Errorf("error: cannot load package %s: %s", dir, err)
+	}
+	importPath := strings.TrimSpace(string(out))
+
+	// the program lives inside the package so that it builds with the same
+	// module, and can import internal packages
+	tmpDir, err := ioutil.TempDir(dir, "_hummusgen")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	err = ioutil.WriteFile(filepath.Join(tmpDir, "main.go"), ExplainProgram(importPath, typeName), 0644)
+	if err != nil {
+		return err
+	}
+
+	run := exec.Command("go", "run", ".")
+	run.Dir = tmpDir
+	run.Stdout = stdout
+	run.Stderr = stderr
+	if err := run.Run(); err != nil {
+		return fmt.Errorf("error: cannot explain %s.%s: %s", importPath, typeName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExplainProgram", func() {
+	It("generates a program that explains the type", func() {
+		program := ExplainProgram("example.com/foods/models", "Info")
+
+		file, err := parser.ParseFile(token.NewFileSet(), "main.go", program, parser.ImportsOnly)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file.Name.Name).To(Equal("main"))
+		Expect(file.Imports).To(HaveLen(4))
+		Expect(file.Imports[3].Path.Value).To(Equal(`"example.com/foods/models"`))
+
+		Expect(string(program)).To(ContainSubstring("hummus.Explain(reflect.TypeOf((*explained.Info)(nil)).Elem())"))
+	})
+})
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode"
+
+	"github.com/aditya87/hummus"
+)
+
+var (
+	wordRegex   = regexp.MustCompile(`[A-Za-z0-9]+`)
+	numberRegex = regexp.MustCompile(`\d+|\D+`)
+)
+
+type genField struct {
+	name  string
+	path  string
+	typ   reflect.Type
+	value interface{}
+}
+
+// GenerateStruct generates a Go struct with one flat, hummus-tagged field for
+// every leaf of the sample JSON document, so that marshaling the struct gives
+// back a document of the same shape. If jsonTags is set, fields also get json
+// tags named after their paths, which matches the keys of hummus.Flatten.
+func GenerateStruct(sample []byte, typeName string, jsonTags bool) ([]byte, error) {
+	fields, err := inferFields(sample)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "type %s struct {\n", typeName)
+	for _, f := range fields {
+		tag := fmt.Sprintf("hummus:%q", f.path)
+		if jsonTags {
+			tag = fmt.Sprintf("json:%q %s", f.path, tag)
+		}
+		fmt.Fprintf(&buf, "%s %s `%s`\n", f.name, f.typ, tag)
+	}
+	buf.WriteString("}\n")
+
+	return format.Source(buf.Bytes())
+}
+
+func inferFields(sample []byte) ([]genField, error) {
+	flat, err := hummus.Flatten(sample)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for path := range flat {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return naturalLess(paths[i], paths[j])
+	})
+
+	var fields []genField
+	used := make(map[string]bool)
+	for _, path := range paths {
+		typ, value := inferType(flat[path])
+
+		base := fieldName(path)
+		name := base
+		for n := 2; used[name]; n++ {
+			name = base + strconv.Itoa(n)
+		}
+		used[name] = true
+
+		fields = append(fields, genField{
+			name:  name,
+			path:  path,
+			typ:   typ,
+			value: value,
+		})
+	}
+
+	return fields, nil
+}
+
+func inferType(value interface{}) (reflect.Type, interface{}) {
+	switch v := value.(type) {
+	case string:
+		return reflect.TypeOf(""), v
+	case bool:
+		return reflect.TypeOf(false), v
+	case json.Number:
+		if i, err := v.Int64(); err == nil && i >= math.MinInt && i <= math.MaxInt {
+			return reflect.TypeOf(0), int(i)
+		}
+
+		f, _ := v.Float64()
+		return reflect.TypeOf(float64(0)), f
+	case []interface{}:
+		return reflect.TypeOf([]interface{}{}), v
+	case map[string]interface{}:
+		return reflect.TypeOf(map[string]interface{}{}), v
+	}
+
+	return reflect.TypeOf((*interface{})(nil)).Elem(), value
+}
+
+// fieldName turns a path like "brands[0].main_supplier" into an exported Go
+// identifier like "Brands0MainSupplier"
+func fieldName(path string) string {
+	var name string
+	for _, word := range wordRegex.FindAllString(path, -1) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		name += string(runes)
+	}
+
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "Field" + name
+	}
+
+	return name
+}
+
+// naturalLess orders paths so that "brands[2]" comes before "brands[10]"
+func naturalLess(a, b string) bool {
+	aParts := numberRegex.FindAllString(a, -1)
+	bParts := numberRegex.FindAllString(b, -1)
+
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		if aParts[i] == bParts[i] {
+			continue
+		}
+
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		if aErr == nil && bErr == nil {
+			return aNum < bNum
+		}
+
+		return aParts[i] < bParts[i]
+	}
+
+	return len(aParts) < len(bParts)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GenerateStruct", func() {
+	sample := []byte(`{
+		"company": "hello foods",
+		"public": true,
+		"brands": [
+			{
+				"name": "sabra",
+				"stores": [
+					{"name": "safeway", "price": 5},
+					{"name": "wholefoods", "price": 10.5}
+				]
+			}
+		],
+		"properties.name": null,
+		"empty": {},
+		"matrix": [[1, 2]]
+	}`)
+
+	It("generates a flat tagged struct", func() {
+		source, err := GenerateStruct(sample, "Info", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(source)).To(Equal("type Info struct {\n" +
+			"\tBrands0Name         string                 `hummus:\"brands[0].name\"`\n" +
+			"\tBrands0Stores0Name  string                 `hummus:\"brands[0].stores[0].name\"`\n" +
+			"\tBrands0Stores0Price int                    `hummus:\"brands[0].stores[0].price\"`\n" +
+			"\tBrands0Stores1Name  string                 `hummus:\"brands[0].stores[1].name\"`\n" +
+			"\tBrands0Stores1Price float64                `hummus:\"brands[0].stores[1].price\"`\n" +
+			"\tCompany             string                 `hummus:\"company\"`\n" +
+			"\tEmpty               map[string]interface{} `hummus:\"empty\"`\n" +
+			"\tMatrix0             []interface{}          `hummus:\"matrix[0]\"`\n" +
+			"\tPropertiesName      interface{}            `hummus:\"properties#name\"`\n" +
+			"\tPublic              bool                   `hummus:\"public\"`\n" +
+			"}\n"))
+	})
+
+	It("generates json tags named after the paths", func() {
+		source, err := GenerateStruct([]byte(`{"brands": [{"name": "sabra"}]}`), "Info", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(source)).To(Equal("type Info struct {\n" +
+			"\tBrands0Name string `json:\"brands[0].name\" hummus:\"brands[0].name\"`\n" +
+			"}\n"))
+	})
+
+	It("round-trips through Marshal", func() {
+		for _, sample := range [][]byte{sample, []byte(`{"/api": {"v": 1}}`)} {
+			fields, err := inferFields(sample)
+			Expect(err).NotTo(HaveOccurred())
+
+			var structFields []reflect.StructField
+			for _, f := range fields {
+				structFields = append(structFields, reflect.StructField{
+					Name: f.name,
+					Type: f.typ,
+					Tag:  reflect.StructTag(`hummus:"` + f.path + `"`),
+				})
+			}
+
+			v := reflect.New(reflect.StructOf(structFields)).Elem()
+			for i, f := range fields {
+				if f.value != nil {
+					v.Field(i).Set(reflect.ValueOf(f.value))
+				}
+			}
+
+			outJSON, err := hummus.Marshal(v.Interface())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outJSON).To(MatchJSON(sample))
+		}
+	})
+
+	It("gives fields with clashing names unique names", func() {
+		source, err := GenerateStruct([]byte(`{"a_b": 1, "a.b": 2, "9": 3}`), "Info", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(source)).To(Equal("type Info struct {\n" +
+			"\tField9 int `hummus:\"9\"`\n" +
+			"\tAB     int `hummus:\"a#b\"`\n" +
+			"\tAB2    int `hummus:\"a_b\"`\n" +
+			"}\n"))
+	})
+
+	It("gives fields unique names when a suffixed name is taken too", func() {
+		source, err := GenerateStruct([]byte(`{"a_b": 1, "a.b": 2, "a_b_2": 3}`), "Info", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(source)).To(Equal("type Info struct {\n" +
+			"\tAB   int `hummus:\"a#b\"`\n" +
+			"\tAB2  int `hummus:\"a_b\"`\n" +
+			"\tAB22 int `hummus:\"a_b_2\"`\n" +
+			"}\n"))
+	})
+
+	Context("when the sample is not a JSON object", func() {
+		It("returns an error", func() {
+			_, err := GenerateStruct([]byte(`[1, 2]`), "Info", false)
+			Expect(err).To(MatchError("error: can only flatten JSON objects"))
+		})
+	})
+})
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+const usage = `usage: hummusgen <command> [arguments]
+
+Commands:
+  fromjson sample.json [-type Info] [-json]
+        generate a flat hummus-tagged Go struct from a sample JSON document
+  explain -type Info [package directory]
+        print the document skeleton hummus produces for a struct type
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "fromjson":
+		err = fromJSON(os.Args[2:])
+	case "explain":
+		err = explain(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func fromJSON(args []string) error {
+	fs := flag.NewFlagSet("fromjson", flag.ExitOnError)
+	typeName := fs.String("type", "Info", "name of the generated struct")
+	jsonTags := fs.Bool("json", false, "also generate json tags")
+
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(files) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	sample, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		return err
+	}
+
+	source, err := GenerateStruct(sample, *typeName, *jsonTags)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(source)
+	return err
+}
+
+func explain(args []string) error {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	typeName := fs.String("type", "", "name of the struct type to explain")
+
+	dirs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *typeName == "" || len(dirs) > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if len(dirs) == 1 {
+		dir = dirs[0]
+	}
+
+	return runExplain(dir, *typeName, os.Stdout, os.Stderr)
+}
+
+// parseInterspersed parses flags that come before or after the positional
+// arguments, e.g. "sample.json -type Info"
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,114 @@
+package hummus
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var exprFieldRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// isComputed reports whether a field's value comes from its tag rather than
+// the field itself
+func (ht hummusTag) isComputed() bool {
+	return ht.hasExpr || ht.hasConst
+}
+
+// computedValue evaluates the expr or const option of a field of the struct v.
+// Expressions replace {Field} (or {Nested.Field}) with the values of sibling
+// fields, and constants are taken as they are for strings and blank struct{}
+// fields and decoded as JSON otherwise.
+func computedValue(ht hummusTag, field reflect.StructField, v reflect.Value) (interface{}, error) {
+	if ht.hasConst {
+		if field.Type == reflect.TypeOf(struct{}{}) {
+			return ht.constValue, nil
+		}
+
+		value, err := defaultValue(field, ht.constValue)
+		if err != nil {
+			return nil, err
+		}
+		return value.Interface(), nil
+	}
+
+	var err error
+	result := exprFieldRegex.ReplaceAllStringFunc(ht.expr, func(match string) string {
+		name := match[1 : len(match)-1]
+
+		fieldValue, ok := lookupField(v, name)
+		if !ok {
+			err = fmt.Errorf("error: unknown field %s in expr %s", name, ht.expr)
+			return ""
+		}
+
+		if fieldValue.Kind() == reflect.Ptr {
+			if fieldValue.IsNil() {
+				return ""
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		return fmt.Sprint(fieldValue.Interface())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// computedType returns the type of the values computed for a field
+func computedType(ht hummusTag, field reflect.StructField) reflect.Type {
+	if ht.hasConst && field.Type != reflect.TypeOf(struct{}{}) {
+		return field.Type
+	}
+
+	return reflect.TypeOf("")
+}
+
+// checkComputed reports expressions that refer to fields t doesn't have, and
+// constants that can't be decoded
+func checkComputed(ht hummusTag, field reflect.StructField, t reflect.Type) error {
+	if ht.hasConst {
+		_, err := computedValue(ht, field, reflect.Value{})
+		return err
+	}
+
+	for _, match := range exprFieldRegex.FindAllStringSubmatch(ht.expr, -1) {
+		if _, ok := lookupFieldType(t, match[1]); !ok {
+			return fmt.Errorf("error: unknown field %s in expr %s", match[1], ht.expr)
+		}
+	}
+
+	return nil
+}
+
+// lookupField returns the exported field of the struct v with a (dotted) name
+func lookupField(v reflect.Value, name string) (reflect.Value, bool) {
+	if _, ok := lookupFieldType(v.Type(), name); !ok {
+		return reflect.Value{}, false
+	}
+
+	for _, part := range strings.Split(name, ".") {
+		v = v.FieldByName(part)
+	}
+
+	return v, true
+}
+
+func lookupFieldType(t reflect.Type, name string) (reflect.Type, bool) {
+	for _, part := range strings.Split(name, ".") {
+		if t.Kind() != reflect.Struct {
+			return nil, false
+		}
+
+		field, ok := t.FieldByName(part)
+		if !ok || field.PkgPath != "" {
+			return nil, false
+		}
+		t = field.Type
+	}
+
+	return t, true
+}
@@ -0,0 +1,96 @@
+package hummus_test
+
+import (
+	"reflect"
+
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type ComputedName struct {
+	First string `hummus:"first"`
+	Last  string `hummus:"last"`
+}
+
+type ComputedCustomer struct {
+	Name    ComputedName `hummus:"customer"`
+	Age     *int         `hummus:"customer.age"`
+	_       struct{}     `hummus:"customer.display,expr={Name.First} {Name.Last} ({Age})"`
+	_       struct{}     `hummus:"apiVersion,const=v2"`
+	Version int          `hummus:"version,const=2"`
+}
+
+var _ = Describe("Computed fields", func() {
+	age := 42
+	customer := ComputedCustomer{
+		Name: ComputedName{First: "Ada", Last: "Lovelace"},
+		Age:  &age,
+	}
+
+	It("derives values from sibling fields and constants", func() {
+		outJSON, err := hummus.Marshal(customer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`
+		{
+			"apiVersion": "v2",
+			"version": 2,
+			"customer": {
+				"first": "Ada",
+				"last": "Lovelace",
+				"age": 42,
+				"display": "Ada Lovelace (42)"
+			}
+		}`))
+	})
+
+	It("leaves computed fields alone in Unmarshal", func() {
+		var output ComputedCustomer
+		err := hummus.Unmarshal([]byte(`{"customer": {"first": "Ada", "display": "someone else"}, "version": 3}`), &output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Name.First).To(Equal("Ada"))
+		Expect(output.Version).To(Equal(0))
+	})
+
+	It("describes constants in schemas", func() {
+		schema, err := hummus.Schema(reflect.TypeOf(ComputedCustomer{}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(schema)).To(ContainSubstring(`"apiVersion":{"const":"v2","type":"string"}`))
+		Expect(string(schema)).To(ContainSubstring(`"display":{"type":"string"}`))
+	})
+
+	It("reads commas in option values", func() {
+		type Info struct {
+			Name  ComputedName `hummus:"name"`
+			_     struct{}     `hummus:"display,expr={Name.Last}, {Name.First}"`
+			Sizes []int        `hummus:"sizes,default=[1,2],omitempty"`
+		}
+
+		outJSON, err := hummus.Marshal(Info{Name: ComputedName{First: "Ada", Last: "Lovelace"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{
+			"name": {"first": "Ada", "last": "Lovelace"},
+			"display": "Lovelace, Ada",
+			"sizes": [1, 2]
+		}`))
+	})
+
+	It("passes Check", func() {
+		Expect(hummus.Check(ComputedCustomer{})).To(Succeed())
+	})
+
+	Context("special/failure cases", func() {
+		Context("when an expr refers to a field that doesn't exist", func() {
+			It("returns an error", func() {
+				type Info struct {
+					First string   `hummus:"first"`
+					_     struct{} `hummus:"display,expr={First} {Middle}"`
+				}
+
+				_, err := hummus.Marshal(Info{})
+				Expect(err).To(MatchError("error: field _: unknown field Middle in expr {First} {Middle}"))
+				Expect(hummus.Check(Info{})).To(MatchError("error: field Info._: unknown field Middle in expr {First} {Middle}"))
+			})
+		})
+	})
+})
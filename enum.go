@@ -0,0 +1,91 @@
+package hummus
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type enumMapping struct {
+	names  map[interface{}]string
+	values map[string]reflect.Value
+}
+
+var (
+	enumsMu sync.RWMutex
+	enums   = make(map[reflect.Type]enumMapping)
+)
+
+// RegisterEnum registers the strings that values of the enum type t are written
+// as by fields tagged with the enum option, e.g. hummus:"status,enum". The keys
+// of names are converted to t, so untyped constants work too. It panics if t is
+// already registered, or if a key or string is used twice.
+func RegisterEnum(t reflect.Type, names map[interface{}]string) {
+	mapping := enumMapping{
+		names:  make(map[interface{}]string),
+		values: make(map[string]reflect.Value),
+	}
+
+	for key, name := range names {
+		value := reflect.ValueOf(key)
+		if !value.Type().ConvertibleTo(t) {
+			panic(fmt.Sprintf("hummus: enum value %v is not a %s", key, t))
+		}
+		value = value.Convert(t)
+
+		if _, exists := mapping.names[value.Interface()]; exists {
+			panic(fmt.Sprintf("hummus: enum value %v of %s registered twice", key, t))
+		}
+		if _, exists := mapping.values[name]; exists {
+			panic(fmt.Sprintf("hummus: enum string %s of %s registered twice", name, t))
+		}
+
+		mapping.names[value.Interface()] = name
+		mapping.values[name] = value
+	}
+
+	enumsMu.Lock()
+	defer enumsMu.Unlock()
+
+	if _, exists := enums[t]; exists {
+		panic(fmt.Sprintf("hummus: enum %s registered twice", t))
+	}
+	enums[t] = mapping
+}
+
+func lookupEnum(t reflect.Type) (enumMapping, bool) {
+	enumsMu.RLock()
+	defer enumsMu.RUnlock()
+
+	mapping, ok := enums[t]
+	return mapping, ok
+}
+
+// enumName returns the string a value of an enum is written as
+func enumName(ht hummusTag, v reflect.Value) (string, error) {
+	mapping, _ := lookupEnum(v.Type())
+
+	name, ok := mapping.names[v.Interface()]
+	if !ok {
+		return "", fmt.Errorf("error: unknown value %v of enum %s at path %s", v.Interface(), v.Type(), ht.tagName)
+	}
+
+	return name, nil
+}
+
+// enumValue returns the value of an enum written as value
+func enumValue(ht hummusTag, t reflect.Type, value interface{}) (reflect.Value, error) {
+	mapping, _ := lookupEnum(t)
+
+	name, ok := value.(string)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("error: expected a string for enum %s at path %s, got %v", t, ht.tagName, value)
+	}
+
+	v, ok := mapping.values[name]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("error: unknown string %s for enum %s at path %s", name, t, ht.tagName)
+	}
+
+	return v, nil
+}
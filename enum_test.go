@@ -0,0 +1,95 @@
+package hummus_test
+
+import (
+	"reflect"
+
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type EnumStatus int
+
+const (
+	EnumActive EnumStatus = iota + 1
+	EnumSuspended
+)
+
+type EnumAccount struct {
+	Status   EnumStatus  `hummus:"account.status,enum"`
+	Previous *EnumStatus `hummus:"account.previous,enum"`
+}
+
+func init() {
+	hummus.RegisterEnum(reflect.TypeOf(EnumActive), map[interface{}]string{
+		EnumActive:    "ACTIVE",
+		EnumSuspended: "SUSPENDED",
+	})
+}
+
+var _ = Describe("Enums", func() {
+	It("writes enum values as strings", func() {
+		previous := EnumActive
+		outJSON, err := hummus.Marshal(EnumAccount{Status: EnumSuspended, Previous: &previous})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{"account": {"status": "SUSPENDED", "previous": "ACTIVE"}}`))
+	})
+
+	It("maps strings back in Unmarshal", func() {
+		var output EnumAccount
+		err := hummus.Unmarshal([]byte(`{"account": {"status": "ACTIVE", "previous": "SUSPENDED"}}`), &output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Status).To(Equal(EnumActive))
+		Expect(*output.Previous).To(Equal(EnumSuspended))
+	})
+
+	It("converts untyped keys to the enum type", func() {
+		type Level uint8
+		hummus.RegisterEnum(reflect.TypeOf(Level(0)), map[interface{}]string{1: "LOW", 2: "HIGH"})
+
+		outJSON, err := hummus.Marshal(struct {
+			Level Level `hummus:"level,enum"`
+		}{Level: 2})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{"level": "HIGH"}`))
+	})
+
+	Context("special/failure cases", func() {
+		Context("when a value isn't registered", func() {
+			It("returns an error naming the field and path", func() {
+				_, err := hummus.Marshal(EnumAccount{Status: 7})
+				Expect(err).To(MatchError("error: field Status: unknown value 7 of enum hummus_test.EnumStatus at path account.status"))
+			})
+		})
+
+		Context("when a string isn't registered", func() {
+			It("returns an error naming the field and path", func() {
+				var output EnumAccount
+				err := hummus.Unmarshal([]byte(`{"account": {"status": "CLOSED"}}`), &output)
+				Expect(err).To(MatchError("error: field Status (path account.status): unknown string CLOSED for enum hummus_test.EnumStatus at path account.status"))
+			})
+		})
+
+		Context("when the type isn't a registered enum", func() {
+			It("returns an error", func() {
+				_, err := hummus.Marshal(struct {
+					Status int `hummus:"status,enum"`
+				}{})
+				Expect(err).To(MatchError("error: field Status: int isn't a registered enum"))
+			})
+		})
+
+		Context("when an enum is registered twice", func() {
+			It("panics", func() {
+				Expect(func() {
+					hummus.RegisterEnum(reflect.TypeOf(EnumActive), map[interface{}]string{})
+				}).To(Panic())
+
+				Expect(func() {
+					type Color int
+					hummus.RegisterEnum(reflect.TypeOf(Color(0)), map[interface{}]string{1: "RED", 2: "RED"})
+				}).To(Panic())
+			})
+		})
+	})
+})
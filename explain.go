@@ -0,0 +1,278 @@
+package hummus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aditya87/hummus/tree"
+)
+
+type fieldExplanation struct {
+	field string
+	typ   reflect.Type
+	tag   hummusTag
+	value reflect.Value
+	tags  Tags
+}
+
+type explainer struct {
+	tree       tree.Tree
+	withValues bool
+	warnings   []string
+	tags       Tags
+}
+
+// Explain describes the documents that Marshal produces from values of type t:
+// the nested skeleton, with every leaf annotated with the Go field it comes
+// from, followed by warnings about skipped fields, index gaps and conflicts.
+func Explain(t reflect.Type) string {
+	return Tags{}.Explain(t)
+}
+
+// Explain is like the package-level Explain, but explains the chosen tags
+func (tags Tags) Explain(t reflect.Type) string {
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Sprintf("error: can only explain structs, got %s\n", t)
+	}
+
+	e := &explainer{tree: tree.NewTree(), tags: tags}
+	e.insertFields(t, reflect.Value{}, "", "")
+
+	return e.String()
+}
+
+// ExplainValue is Explain for a single value: every leaf is also annotated with
+// the JSON that Marshal produces for it, and omitted fields are listed.
+func ExplainValue(input interface{}) string {
+	return Tags{}.ExplainValue(input)
+}
+
+// ExplainValue is like the package-level ExplainValue, but explains the chosen
+// tags
+func (tags Tags) ExplainValue(input interface{}) string {
+	t := reflect.TypeOf(input)
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Sprintf("error: can only explain structs, got %s\n", t)
+	}
+
+	e := &explainer{tree: tree.NewTree(), withValues: true, tags: tags}
+	e.insertFields(t, reflect.ValueOf(input), "", "")
+
+	return e.String()
+}
+
+func (e *explainer) insertFields(t reflect.Type, v reflect.Value, prefix, fieldPrefix string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := fieldPrefix + field.Name
+
+		tag, ht, err := e.tags.parseField(field)
+		if tag.Get("hummus") == "" {
+			e.warnf("field %s has no hummus tag and is skipped", name)
+			continue
+		} else if err != nil {
+			e.warnf("field %s: %s", name, errorText(err))
+			continue
+		}
+
+		if field.PkgPath != "" && !ht.isComputed() {
+			e.warnf("field %s is unexported and can't be marshaled", name)
+			continue
+		}
+
+		path := ht.tagName
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		typ := field.Type
+		var fieldValue reflect.Value
+		if ht.isComputed() {
+			typ = computedType(ht, field)
+		}
+
+		if e.withValues && ht.isComputed() {
+			computed, err := computedValue(ht, field, v)
+			if err != nil {
+				e.warnf("field %s: %s", name, errorText(err))
+				continue
+			}
+			fieldValue = reflect.ValueOf(computed)
+		} else if e.withValues {
+			fieldValue = v.Field(i)
+			if ht.hasDefault && isEmptyValue(fieldValue) {
+				fieldValue, err = defaultValue(field, ht.defaultValue)
+				if err != nil {
+					e.warnf("field %s: %s", name, errorText(err))
+					continue
+				}
+			}
+
+			if ht.omitEmpty && isEmptyValue(fieldValue) {
+				e.warnf("field %s is empty and omitted", name)
+				continue
+			}
+		}
+
+		if ht.kind(field.Type) == nestedField {
+			e.insertFields(field.Type, fieldValue, path, name+".")
+			continue
+		}
+
+		err = e.tree.Insert(fmt.Sprintf("hummus:%q", path), &fieldExplanation{
+			field: name,
+			typ:   typ,
+			tag:   ht,
+			value: fieldValue,
+			tags:  e.tags,
+		}, false)
+		if err != nil {
+			e.warnf("field %s: %s", name, errorText(err))
+		}
+	}
+}
+
+func (e *explainer) warnf(format string, args ...interface{}) {
+	e.warnings = append(e.warnings, fmt.Sprintf(format, args...))
+}
+
+func (e *explainer) String() string {
+	var buf bytes.Buffer
+	var gaps []string
+	renderExplanation(&buf, e.tree.BuildJSON().Data(), "", 0, &gaps)
+	buf.WriteString("\n")
+
+	for _, warning := range append(e.warnings, gaps...) {
+		fmt.Fprintf(&buf, "warning: %s\n", warning)
+	}
+
+	return buf.String()
+}
+
+// renderExplanation writes the skeleton of doc, collecting warnings about array
+// elements that no field sets along the way
+func renderExplanation(buf *bytes.Buffer, doc interface{}, path string, depth int, gaps *[]string) {
+	switch value := doc.(type) {
+	case *fieldExplanation:
+		buf.WriteString(value.String())
+	case map[string]interface{}:
+		if len(value) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+
+		var keys []string
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf.WriteString("{\n")
+		for i, key := range keys {
+			childPath := strings.Replace(key, ".", "#", -1)
+			if path != "" {
+				childPath = path + "." + childPath
+			}
+
+			buf.WriteString(explainIndent(depth+1) + strconv.Quote(key) + ": ")
+			renderExplanation(buf, value[key], childPath, depth+1, gaps)
+			if i < len(keys)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(explainIndent(depth) + "}")
+	case []interface{}:
+		if len(value) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+
+		buf.WriteString("[\n")
+		for i, element := range value {
+			elementPath := fmt.Sprintf("%s[%d]", path, i)
+			if element == nil {
+				*gaps = append(*gaps, fmt.Sprintf("no field sets %s, which will be null", elementPath))
+			}
+
+			buf.WriteString(explainIndent(depth + 1))
+			renderExplanation(buf, element, elementPath, depth+1, gaps)
+			if i < len(value)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(explainIndent(depth) + "]")
+	case nil:
+		buf.WriteString("null")
+	default:
+		fmt.Fprintf(buf, "%v", value)
+	}
+}
+
+func (f *fieldExplanation) String() string {
+	s := f.field + " " + f.typ.String()
+
+	var names []string
+	for name := range f.tag.options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if value := f.tag.options[name]; value != "" {
+			s += " (" + name + "=" + value + ")"
+		} else {
+			s += " (" + name + ")"
+		}
+	}
+
+	if f.value.IsValid() {
+		s += " = " + explainJSON(f.tag, f.value, f.tags)
+	}
+
+	return s
+}
+
+// explainJSON returns the JSON that Marshal produces for a leaf value
+func explainJSON(ht hummusTag, v reflect.Value, tags Tags) string {
+	data := v.Interface()
+
+	var err error
+	if ht.isFormatted() {
+		data, err = formatValue(ht, v)
+	} else if ht.asString && quotable(v.Type()) {
+		data, err = quoteValue(v)
+	}
+	if err != nil {
+		return fmt.Sprintf("<%s>", err)
+	}
+
+	if ht.kind(v.Type()) == structSliceField && !v.IsNil() {
+		var elements []interface{}
+		for i := 0; i < v.Len(); i++ {
+			element, err := marshalReflect(v.Index(i).Type(), v.Index(i), Options{Tags: tags})
+			if err != nil {
+				return fmt.Sprintf("<%s>", err)
+			}
+			elements = append(elements, element.Data())
+		}
+		data = elements
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Sprintf("<%s>", err)
+	}
+
+	return string(b)
+}
+
+func explainIndent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
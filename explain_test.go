@@ -0,0 +1,121 @@
+package hummus_test
+
+import (
+	"reflect"
+
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type ExplainStore struct {
+	Name string `hummus:"name"`
+}
+
+type ExplainReputation struct {
+	Stores []ExplainStore `hummus:"stores"`
+	Rating int            `hummus:"rating,omitempty"`
+}
+
+type ExplainInfo struct {
+	Company    string            `hummus:"company"`
+	Brand0Name string            `hummus:"brands[0].name"`
+	Brand2Name string            `hummus:"brands[2].name,omitempty"`
+	Escaped    bool              `hummus:"properties#name"`
+	Reputation ExplainReputation `hummus:"reputation"`
+	Untagged   string
+}
+
+var _ = Describe("Explain", func() {
+	It("annotates the nested skeleton with the fields that set each path", func() {
+		Expect(hummus.Explain(reflect.TypeOf(ExplainInfo{}))).To(Equal(`{
+  "brands": [
+    {
+      "name": Brand0Name string
+    },
+    null,
+    {
+      "name": Brand2Name string (omitempty)
+    }
+  ],
+  "company": Company string,
+  "properties.name": Escaped bool,
+  "reputation": {
+    "rating": Reputation.Rating int (omitempty),
+    "stores": Reputation.Stores []hummus_test.ExplainStore
+  }
+}
+warning: field Untagged has no hummus tag and is skipped
+warning: no field sets brands[1], which will be null
+`))
+	})
+
+	It("warns about conflicts and fields Marshal can't handle", func() {
+		type Info struct {
+			Name     string `hummus:"brand.name"`
+			Brand    string `hummus:"brand"`
+			Invalid  string `hummus:"invalid,omitempty,blah"`
+			internal string `hummus:"internal"`
+		}
+
+		Expect(hummus.Explain(reflect.TypeOf(Info{}))).To(Equal(`{
+  "brand": {
+    "name": Name string
+  }
+}
+warning: field Brand: conflicting values for path brand
+warning: field Invalid: unknown struct tag option blah
+warning: field internal is unexported and can't be marshaled
+`))
+	})
+
+	It("shows every option of a field", func() {
+		type Info struct {
+			Currency string  `hummus:"currency,required,default=USD,groups=admin|support"`
+			Price    float64 `hummus:"price,string,precision=2,omitempty"`
+			Token    []byte  `hummus:"token,bytes=hex,redact"`
+		}
+
+		Expect(hummus.Explain(reflect.TypeOf(Info{}))).To(Equal(`{
+  "currency": Currency string (default=USD) (groups=admin|support) (required),
+  "price": Price float64 (omitempty) (precision=2) (string),
+  "token": Token []uint8 (bytes=hex) (redact)
+}
+`))
+	})
+
+	Context("when passed a type that isn't a struct", func() {
+		It("says so", func() {
+			Expect(hummus.Explain(reflect.TypeOf(""))).To(Equal("error: can only explain structs, got string\n"))
+		})
+	})
+})
+
+var _ = Describe("ExplainValue", func() {
+	It("annotates every leaf with the value Marshal produces for it", func() {
+		info := ExplainInfo{
+			Company:    "hello foods",
+			Brand0Name: "sabra",
+			Reputation: ExplainReputation{
+				Stores: []ExplainStore{{Name: "safeway"}},
+			},
+		}
+
+		Expect(hummus.ExplainValue(info)).To(Equal(`{
+  "brands": [
+    {
+      "name": Brand0Name string = "sabra"
+    }
+  ],
+  "company": Company string = "hello foods",
+  "properties.name": Escaped bool = false,
+  "reputation": {
+    "stores": Reputation.Stores []hummus_test.ExplainStore = [{"name":"safeway"}]
+  }
+}
+warning: field Brand2Name is empty and omitted
+warning: field Reputation.Rating is empty and omitted
+warning: field Untagged has no hummus tag and is skipped
+`))
+	})
+})
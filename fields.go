@@ -0,0 +1,97 @@
+package hummus
+
+import (
+	"strings"
+)
+
+// MarshalFields is like Marshal, but only writes the fields at the given paths
+// (e.g. "brands[0].name" or "company"), inside them or containing them. A *
+// matches any key and [*] any index, e.g. "brands[*].name". Nested structs and
+// slices of structs are only marshaled as far as they're selected, but other
+// values are written whole. No paths selects every field.
+func MarshalFields(input interface{}, paths ...string) ([]byte, error) {
+	return MarshalWithOptions(input, Options{Fields: paths})
+}
+
+// selectField reports whether the field at path is selected, and returns the
+// options for marshaling its value, whose fields are relative to path
+func (opts Options) selectField(path string) (bool, Options) {
+	if len(opts.Fields) == 0 {
+		return true, opts
+	}
+
+	fieldSegments := pathSegments(path)
+
+	var whole bool
+	var inside []string
+	for _, selected := range opts.Fields {
+		selectedSegments := pathSegments(selected)
+
+		if len(selectedSegments) <= len(fieldSegments) {
+			if segmentsMatch(selectedSegments, fieldSegments[:len(selectedSegments)]) {
+				whole = true
+			}
+		} else if segmentsMatch(selectedSegments[:len(fieldSegments)], fieldSegments) {
+			inside = append(inside, joinSegments(selectedSegments[len(fieldSegments):]))
+		}
+	}
+
+	nested := opts
+	nested.Fields = inside
+	if whole {
+		nested.Fields = nil
+	}
+
+	return whole || len(inside) > 0, nested
+}
+
+// pathSegments splits a path into its keys and [index] segments
+func pathSegments(path string) []string {
+	var segments []string
+
+	for _, part := range strings.Split(path, ".") {
+		for part != "" {
+			end := strings.Index(part, "[")
+			if part[0] == '[' {
+				end = strings.Index(part, "]") + 1
+			}
+			if end <= 0 {
+				end = len(part)
+			}
+
+			segments = append(segments, part[:end])
+			part = part[end:]
+		}
+	}
+
+	return segments
+}
+
+func joinSegments(segments []string) string {
+	var path string
+	for i, segment := range segments {
+		if i > 0 && !isIndexSegment(segment) {
+			path += "."
+		}
+		path += segment
+	}
+
+	return path
+}
+
+func segmentsMatch(selected, segments []string) bool {
+	for i, segment := range segments {
+		switch {
+		case selected[i] == "*" && !isIndexSegment(segment):
+		case selected[i] == "[*]" && isIndexSegment(segment):
+		case selected[i] != segment:
+			return false
+		}
+	}
+
+	return true
+}
+
+func isIndexSegment(segment string) bool {
+	return strings.HasPrefix(segment, "[")
+}
@@ -0,0 +1,101 @@
+package hummus_test
+
+import (
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type FieldsStore struct {
+	Name  string  `hummus:"name"`
+	Price float64 `hummus:"price"`
+}
+
+type FieldsBrand struct {
+	Name   string        `hummus:"name"`
+	Stores []FieldsStore `hummus:"stores"`
+}
+
+type FieldsExpensive struct {
+	Value string `hummus:"value"`
+}
+
+func (e FieldsExpensive) BeforeHummus() error {
+	panic("marshaled an unselected struct")
+}
+
+type FieldsInfo struct {
+	Company   string          `hummus:"company"`
+	Country   string          `hummus:"address.country"`
+	City      string          `hummus:"address.city"`
+	Brands    []FieldsBrand   `hummus:"brands"`
+	Flagship  string          `hummus:"flagship[0].name"`
+	Tags      []string        `hummus:"tags"`
+	Expensive FieldsExpensive `hummus:"expensive"`
+}
+
+var _ = Describe("MarshalFields", func() {
+	info := FieldsInfo{
+		Company: "hello foods",
+		Country: "US",
+		City:    "Austin",
+		Brands: []FieldsBrand{
+			{Name: "sabra", Stores: []FieldsStore{{Name: "Whole Foods", Price: 3.5}, {Name: "Safeway", Price: 4}}},
+			{Name: "tribe", Stores: []FieldsStore{{Name: "Kroger", Price: 3}}},
+		},
+		Flagship: "Austin",
+		Tags:     []string{"vegan", "organic"},
+	}
+
+	It("writes the selected paths and what's inside them", func() {
+		outJSON, err := hummus.MarshalFields(info, "company", "address", "tags[0]")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`
+		{
+			"company": "hello foods",
+			"address": {"country": "US", "city": "Austin"},
+			"tags": ["vegan", "organic"]
+		}`))
+	})
+
+	It("selects fields inside nested structs and slice elements", func() {
+		outJSON, err := hummus.MarshalFields(info, "brands[1].name", "brands[0].stores[1]", "flagship[0]")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`
+		{
+			"brands": [
+				{"stores": [null, {"name": "Safeway", "price": 4}]},
+				{"name": "tribe"}
+			],
+			"flagship": [{"name": "Austin"}]
+		}`))
+	})
+
+	It("matches keys and indices with wildcards", func() {
+		outJSON, err := hummus.MarshalFields(info, "brands[*].stores[*].name", "address.*")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`
+		{
+			"address": {"country": "US", "city": "Austin"},
+			"brands": [
+				{"stores": [{"name": "Whole Foods"}, {"name": "Safeway"}]},
+				{"stores": [{"name": "Kroger"}]}
+			]
+		}`))
+	})
+
+	It("leaves out arrays without selected elements", func() {
+		outJSON, err := hummus.MarshalFields(info, "company", "brands[5]")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{"company": "hello foods"}`))
+	})
+
+	It("combines with the other options", func() {
+		outJSON, err := hummus.MarshalWithOptions(OptionsOrder{ID: "o-1", Cost: 12.5}, hummus.Options{
+			Fields: []string{"order", "internal"},
+			Groups: []string{"public"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{"order": {"id": "o-1"}}`))
+	})
+})
@@ -0,0 +1,81 @@
+package hummus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Flatten is the inverse of Nest: it explodes a JSON object into values keyed
+// by hummus paths, escaping literal dots in keys with hashtags. Empty objects
+// and arrays, as well as arrays nested directly inside arrays, are kept whole.
+func Flatten(data []byte) (map[string]interface{}, error) {
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("error: can only flatten JSON objects")
+	}
+
+	flat := make(map[string]interface{})
+	if err := flattenObject(flat, "", obj); err != nil {
+		return nil, err
+	}
+
+	return flat, nil
+}
+
+func flattenObject(flat map[string]interface{}, prefix string, obj map[string]interface{}) error {
+	if len(obj) == 0 && prefix != "" {
+		flat[prefix] = obj
+		return nil
+	}
+
+	for key, value := range obj {
+		if key == "" || strings.ContainsAny(key, "#[],") {
+			return fmt.Errorf("error: key %q cannot be expressed as a hummus path", key)
+		}
+
+		path := strings.Replace(key, ".", "#", -1)
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		if err := flattenValue(flat, path, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func flattenValue(flat map[string]interface{}, path string, value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return flattenObject(flat, path, v)
+	case []interface{}:
+		if len(v) == 0 {
+			flat[path] = v
+			return nil
+		}
+
+		for i, element := range v {
+			elementPath := fmt.Sprintf("%s[%d]", path, i)
+			if _, isArray := element.([]interface{}); isArray {
+				flat[elementPath] = element
+				continue
+			}
+
+			if err := flattenValue(flat, elementPath, element); err != nil {
+				return err
+			}
+		}
+	default:
+		flat[path] = v
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package hummus_test
+
+import (
+	"encoding/json"
+
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Flatten", func() {
+	It("explodes nested JSON into hummus paths", func() {
+		flat, err := hummus.Flatten([]byte(`{
+			"company": "hello foods",
+			"brands": [
+				{
+					"name": "sabra",
+					"stores": [
+						{
+							"name": "safeway",
+							"price": 5
+						}
+					]
+				}
+			],
+			"reputation": {
+				"type": "good"
+			}
+		}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flat).To(Equal(map[string]interface{}{
+			"company":                   "hello foods",
+			"brands[0].name":            "sabra",
+			"brands[0].stores[0].name":  "safeway",
+			"brands[0].stores[0].price": json.Number("5"),
+			"reputation.type":           "good",
+		}))
+	})
+
+	It("escapes literal dots with hashtags", func() {
+		flat, err := hummus.Flatten([]byte(`{
+			"outer": {
+				"inner.notchild": [{"name": "C_val"}],
+				"inner": "B_val"
+			}
+		}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flat).To(Equal(map[string]interface{}{
+			"outer.inner#notchild[0].name": "C_val",
+			"outer.inner":                  "B_val",
+		}))
+	})
+
+	It("keeps empty objects and arrays, and arrays inside arrays, whole", func() {
+		flat, err := hummus.Flatten([]byte(`{
+			"empty_object": {},
+			"empty_array": [],
+			"matrix": [[1, 2], [3]]
+		}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flat).To(Equal(map[string]interface{}{
+			"empty_object": map[string]interface{}{},
+			"empty_array":  []interface{}{},
+			"matrix[0]":    []interface{}{json.Number("1"), json.Number("2")},
+			"matrix[1]":    []interface{}{json.Number("3")},
+		}))
+	})
+
+	It("round-trips through Nest", func() {
+		inJSON := `{
+			"company": "hello foods",
+			"address": null,
+			"brands": [
+				{
+					"name": "sabra",
+					"tasty": true,
+					"stores": [
+						{"name": "safeway", "price": 5.5},
+						null,
+						{"name": "wholefoods", "tags": ["organic", "local"]}
+					]
+				},
+				{}
+			],
+			"outer": {
+				"inner.notchild": {"value": 12345678901234567890},
+				"inner": "B_val"
+			},
+			"matrix": [[1, 2], []],
+			"/api": {"v": 1, "/docs": "/api/docs"}
+		}`
+
+		flat, err := hummus.Flatten([]byte(inJSON))
+		Expect(err).NotTo(HaveOccurred())
+
+		outJSON, err := hummus.Nest(flat)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(inJSON))
+	})
+
+	Context("special/failure cases", func() {
+		Context("when the document is not an object", func() {
+			It("returns an error", func() {
+				_, err := hummus.Flatten([]byte(`["sabra"]`))
+				Expect(err).To(MatchError("error: can only flatten JSON objects"))
+			})
+		})
+
+		Context("when a key cannot be expressed as a path", func() {
+			It("returns an error", func() {
+				_, err := hummus.Flatten([]byte(`{"brand#name": "sabra"}`))
+				Expect(err).To(MatchError(`error: key "brand#name" cannot be expressed as a hummus path`))
+			})
+		})
+
+		Context("when passed invalid JSON", func() {
+			It("returns an error", func() {
+				_, err := hummus.Flatten([]byte(`{"brand": `))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
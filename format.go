@@ -0,0 +1,280 @@
+package hummus
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Formatter converts the value of a field into the value written to the
+// document. Nil pointers are written as null without calling the formatter.
+type Formatter func(value interface{}) (interface{}, error)
+
+type timeFormat struct {
+	format Formatter
+	parse  func(value interface{}) (time.Time, error)
+	typ    reflect.Type
+}
+
+type byteEncoding struct {
+	encode func([]byte) string
+	decode func(string) ([]byte, error)
+}
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = make(map[string]Formatter)
+
+	timeFormats = map[string]timeFormat{
+		"rfc3339": {
+			format: timeFormatter(func(t time.Time) interface{} { return t.Format(time.RFC3339) }),
+			parse:  timeParser(time.RFC3339),
+			typ:    reflect.TypeOf(""),
+		},
+		"rfc3339nano": {
+			format: timeFormatter(func(t time.Time) interface{} { return t.Format(time.RFC3339Nano) }),
+			parse:  timeParser(time.RFC3339Nano),
+			typ:    reflect.TypeOf(""),
+		},
+		"unix": {
+			format: timeFormatter(func(t time.Time) interface{} { return t.Unix() }),
+			parse:  unixParser(time.Second),
+			typ:    reflect.TypeOf(int64(0)),
+		},
+		"unixms": {
+			format: timeFormatter(func(t time.Time) interface{} { return t.UnixNano() / int64(time.Millisecond) }),
+			parse:  unixParser(time.Millisecond),
+			typ:    reflect.TypeOf(int64(0)),
+		},
+	}
+
+	byteEncodings = map[string]byteEncoding{
+		"hex":       {encode: hex.EncodeToString, decode: hex.DecodeString},
+		"base64":    {encode: base64.StdEncoding.EncodeToString, decode: base64.StdEncoding.DecodeString},
+		"base64url": {encode: base64.URLEncoding.EncodeToString, decode: base64.URLEncoding.DecodeString},
+	}
+)
+
+// RegisterFormatter makes a formatter available to the format option, e.g.
+// hummus:"price,format=cents". Custom formatters only apply to Marshal;
+// Unmarshal leaves their fields alone. It panics if the name is already taken.
+func RegisterFormatter(name string, f Formatter) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+
+	if _, exists := timeFormats[name]; exists {
+		panic(fmt.Sprintf("hummus: formatter %s registered twice", name))
+	}
+	if _, exists := formatters[name]; exists {
+		panic(fmt.Sprintf("hummus: formatter %s registered twice", name))
+	}
+	formatters[name] = f
+}
+
+func lookupFormatter(name string) (Formatter, bool) {
+	if tf, ok := timeFormats[name]; ok {
+		return tf.format, true
+	}
+
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+
+	f, ok := formatters[name]
+	return f, ok
+}
+
+func timeFormatter(format func(time.Time) interface{}) Formatter {
+	return func(value interface{}) (interface{}, error) {
+		t, ok := value.(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("error: cannot format %T as a time", value)
+		}
+
+		return format(t), nil
+	}
+}
+
+func timeParser(layout string) func(interface{}) (time.Time, error) {
+	return func(value interface{}) (time.Time, error) {
+		s, ok := value.(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("error: expected a time string, got %v", value)
+		}
+
+		return time.Parse(layout, s)
+	}
+}
+
+func unixParser(unit time.Duration) func(interface{}) (time.Time, error) {
+	return func(value interface{}) (time.Time, error) {
+		n, ok := value.(json.Number)
+		if !ok {
+			return time.Time{}, fmt.Errorf("error: expected a timestamp, got %v", value)
+		}
+
+		i, err := n.Int64()
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return time.Unix(0, i*int64(unit)), nil
+	}
+}
+
+// isFormatted reports whether a field has an option that changes how its value
+// is written
+func (ht hummusTag) isFormatted() bool {
+	return ht.format != "" || ht.hasPrecision || ht.bytes != "" || ht.enum
+}
+
+// isReversible reports whether unformatValue can parse the values of a field
+// back, which it can't for custom formats
+func (ht hummusTag) isReversible() bool {
+	if ht.hasPrecision || ht.enum || ht.bytes != "" || ht.format == "" {
+		return true
+	}
+
+	_, ok := timeFormats[ht.format]
+	return ok
+}
+
+// checkFormat reports formatting options that don't apply to fields of type t
+func checkFormat(ht hummusTag, t reflect.Type) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case ht.enum:
+		if _, ok := lookupEnum(t); !ok {
+			return fmt.Errorf("error: %s isn't a registered enum", t)
+		}
+	case ht.format != "":
+		if _, ok := lookupFormatter(ht.format); !ok {
+			return fmt.Errorf("error: unknown formatter %s", ht.format)
+		}
+		if _, ok := timeFormats[ht.format]; ok && t != reflect.TypeOf(time.Time{}) {
+			return fmt.Errorf("error: format %s only applies to times, not %s", ht.format, t)
+		}
+	case ht.hasPrecision:
+		if t.Kind() != reflect.Float32 && t.Kind() != reflect.Float64 {
+			return fmt.Errorf("error: precision only applies to floats, not %s", t)
+		}
+	case ht.bytes != "":
+		if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("error: bytes only applies to byte slices, not %s", t)
+		}
+	}
+
+	return nil
+}
+
+// formatValue applies the format, precision, bytes or enum option of a field
+// to its value
+func formatValue(ht hummusTag, v reflect.Value) (interface{}, error) {
+	if err := checkFormat(ht, v.Type()); err != nil {
+		return nil, err
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	switch {
+	case ht.enum:
+		return enumName(ht, v)
+	case ht.format != "":
+		f, _ := lookupFormatter(ht.format)
+		return f(v.Interface())
+	case ht.hasPrecision:
+		return json.Number(strconv.FormatFloat(v.Float(), 'f', ht.precision, v.Type().Bits())), nil
+	default:
+		return byteEncodings[ht.bytes].encode(v.Bytes()), nil
+	}
+}
+
+// unformatValue is the reverse of formatValue, for the options that can be
+// reversed
+func unformatValue(ht hummusTag, value interface{}, v reflect.Value) error {
+	if err := checkFormat(ht, v.Type()); err != nil {
+		return err
+	}
+
+	if ht.hasPrecision {
+		return unmarshalValue(value, v)
+	}
+
+	var parsed reflect.Value
+	if ht.enum {
+		t := v.Type()
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		var err error
+		parsed, err = enumValue(ht, t, value)
+		if err != nil {
+			return err
+		}
+	} else if ht.bytes != "" {
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("error: expected a %s string, got %v", ht.bytes, value)
+		}
+
+		b, err := byteEncodings[ht.bytes].decode(s)
+		if err != nil {
+			return err
+		}
+		parsed = reflect.ValueOf(b)
+	} else if tf, ok := timeFormats[ht.format]; ok {
+		t, err := tf.parse(value)
+		if err != nil {
+			return err
+		}
+		parsed = reflect.ValueOf(t)
+	} else {
+		return nil
+	}
+
+	if v.Kind() == reflect.Ptr {
+		ptr := reflect.New(v.Type().Elem())
+		ptr.Elem().Set(parsed.Convert(v.Type().Elem()))
+		v.Set(ptr)
+		return nil
+	}
+
+	v.Set(parsed.Convert(v.Type()))
+	return nil
+}
+
+// formattedType returns the type of the values written for a field of type t
+func formattedType(ht hummusTag, t reflect.Type) reflect.Type {
+	var formatted reflect.Type
+	switch {
+	case ht.enum:
+		formatted = reflect.TypeOf("")
+	case ht.format != "":
+		formatted = reflect.TypeOf((*interface{})(nil)).Elem()
+		if tf, ok := timeFormats[ht.format]; ok {
+			formatted = tf.typ
+		}
+	case ht.bytes != "":
+		formatted = reflect.TypeOf("")
+	default:
+		return t
+	}
+
+	if t.Kind() == reflect.Ptr {
+		return reflect.PtrTo(formatted)
+	}
+	return formatted
+}
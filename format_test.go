@@ -0,0 +1,148 @@
+package hummus_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type FormatOrder struct {
+	Created   time.Time  `hummus:"order.created,format=rfc3339"`
+	Updated   *time.Time `hummus:"order.updated,format=unixms"`
+	Shipped   *time.Time `hummus:"order.shipped,format=unix"`
+	Amount    float64    `hummus:"order.amount,precision=2"`
+	Signature []byte     `hummus:"order.signature,bytes=hex"`
+	Token     []byte     `hummus:"order.token,bytes=base64url"`
+}
+
+var _ = Describe("Formatters", func() {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	updated := created.Add(1500 * time.Millisecond)
+
+	order := FormatOrder{
+		Created:   created,
+		Updated:   &updated,
+		Amount:    10.5,
+		Signature: []byte{0xca, 0xfe},
+		Token:     []byte{0xfb, 0xff},
+	}
+
+	It("formats values with the format, precision and bytes options", func() {
+		outJSON, err := hummus.Marshal(order)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(outJSON)).To(ContainSubstring(`"amount":10.50`))
+		Expect(outJSON).To(MatchJSON(`
+		{
+			"order": {
+				"created": "2024-03-01T12:30:00Z",
+				"updated": 1709296201500,
+				"shipped": null,
+				"amount": 10.50,
+				"signature": "cafe",
+				"token": "-_8="
+			}
+		}`))
+	})
+
+	It("parses formatted values back in Unmarshal", func() {
+		outJSON, err := hummus.Marshal(order)
+		Expect(err).NotTo(HaveOccurred())
+
+		var output FormatOrder
+		Expect(hummus.Unmarshal(outJSON, &output)).To(Succeed())
+		Expect(output.Created.Equal(created)).To(BeTrue())
+		Expect(output.Updated.Equal(updated)).To(BeTrue())
+		Expect(output.Shipped).To(BeNil())
+		Expect(output.Amount).To(Equal(10.5))
+		Expect(output.Signature).To(Equal(order.Signature))
+		Expect(output.Token).To(Equal(order.Token))
+	})
+
+	It("uses registered formatters", func() {
+		hummus.RegisterFormatter("upper", func(value interface{}) (interface{}, error) {
+			return strings.ToUpper(fmt.Sprint(value)), nil
+		})
+
+		input := struct {
+			Status string `hummus:"status,format=upper"`
+		}{
+			Status: "active",
+		}
+
+		outJSON, err := hummus.Marshal(input)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{"status": "ACTIVE"}`))
+
+		Expect(func() {
+			hummus.RegisterFormatter("rfc3339", func(value interface{}) (interface{}, error) { return value, nil })
+		}).To(Panic())
+	})
+
+	It("describes formatted values in schemas", func() {
+		schema, err := hummus.Schema(reflect.TypeOf(FormatOrder{}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schema).To(MatchJSON(`{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"order": {
+					"type": "object",
+					"properties": {
+						"created": {"type": "string"},
+						"updated": {"anyOf": [{"type": "integer"}, {"type": "null"}]},
+						"shipped": {"anyOf": [{"type": "integer"}, {"type": "null"}]},
+						"amount": {"type": "number"},
+						"signature": {"type": "string"},
+						"token": {"type": "string"}
+					},
+					"required": ["amount", "created", "shipped", "signature", "token", "updated"]
+				}
+			},
+			"required": ["order"]
+		}`))
+	})
+
+	Context("special/failure cases", func() {
+		Context("when a formatter doesn't exist", func() {
+			It("returns an error", func() {
+				input := struct {
+					Status string `hummus:"status,format=nope"`
+				}{}
+
+				_, err := hummus.Marshal(input)
+				Expect(err).To(MatchError("error: field Status: unknown formatter nope"))
+			})
+		})
+
+		Context("when an option doesn't apply to the field", func() {
+			It("returns an error", func() {
+				type Info struct {
+					Count int `hummus:"count,precision=2"`
+				}
+
+				_, err := hummus.Marshal(Info{})
+				Expect(err).To(MatchError("error: field Count: precision only applies to floats, not int"))
+				Expect(hummus.Check(Info{})).To(MatchError("error: field Info.Count: precision only applies to floats, not int"))
+			})
+		})
+
+		Context("when an option value is invalid", func() {
+			It("returns an error", func() {
+				_, err := hummus.Marshal(struct {
+					Amount float64 `hummus:"amount,precision=-1"`
+				}{})
+				Expect(err).To(MatchError("error: invalid precision -1"))
+
+				_, err = hummus.Marshal(struct {
+					Data []byte `hummus:"data,bytes=base32"`
+				}{})
+				Expect(err).To(MatchError("error: unknown bytes encoding base32"))
+			})
+		})
+	})
+})
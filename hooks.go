@@ -0,0 +1,60 @@
+package hummus
+
+import (
+	"reflect"
+
+	"github.com/aditya87/hummus/tree"
+)
+
+// BeforeHummusHook is implemented by structs that need to prepare their fields
+// before they are marshaled. It is called on a copy of the struct, so changes
+// don't leak back to the value being marshaled.
+type BeforeHummusHook interface {
+	BeforeHummus() error
+}
+
+// AfterHummusHook is implemented by structs that need to add, remove or
+// rewrite nodes of the tree built from their fields before it is serialized.
+type AfterHummusHook interface {
+	AfterHummus(t *tree.Tree) error
+}
+
+var (
+	beforeHummusHookType = reflect.TypeOf((*BeforeHummusHook)(nil)).Elem()
+	afterHummusHookType  = reflect.TypeOf((*AfterHummusHook)(nil)).Elem()
+)
+
+// beforeHummus calls the BeforeHummus hook of the struct v, if it has one, and
+// returns the struct to marshal
+func beforeHummus(v reflect.Value) (reflect.Value, error) {
+	if !reflect.PtrTo(v.Type()).Implements(beforeHummusHookType) {
+		return v, nil
+	}
+
+	// slice elements are addressable, but still belong to the caller
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+
+	return c, c.Addr().Interface().(BeforeHummusHook).BeforeHummus()
+}
+
+// afterHummus calls the AfterHummus hook of the struct v, if it has one
+func afterHummus(v reflect.Value, t *tree.Tree) error {
+	if !reflect.PtrTo(v.Type()).Implements(afterHummusHookType) {
+		return nil
+	}
+
+	return addressable(v).Addr().Interface().(AfterHummusHook).AfterHummus(t)
+}
+
+// addressable returns v, or an addressable copy of it, so that methods with
+// pointer receivers can be called on it
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
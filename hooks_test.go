@@ -0,0 +1,96 @@
+package hummus_test
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/aditya87/hummus"
+	"github.com/aditya87/hummus/tree"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type HookStore struct {
+	Name string `hummus:"name"`
+	Slug string `hummus:"slug"`
+}
+
+func (s *HookStore) BeforeHummus() error {
+	if s.Name == "" {
+		return errors.New("store without a name")
+	}
+
+	s.Slug = strings.ToLower(strings.Replace(s.Name, " ", "-", -1))
+	return nil
+}
+
+type HookBrand struct {
+	Name     string `hummus:"name"`
+	Internal string `hummus:"internal"`
+}
+
+func (b HookBrand) AfterHummus(t *tree.Tree) error {
+	t.Delete("internal")
+	return t.Insert(`hummus:"source"`, "hooks", false)
+}
+
+type HookInfo struct {
+	Company string      `hummus:"company"`
+	Brand   HookBrand   `hummus:"brand"`
+	Stores  []HookStore `hummus:"stores"`
+}
+
+func (i *HookInfo) AfterHummus(t *tree.Tree) error {
+	if t.Delete("company") {
+		return t.Insert(`hummus:"company"`, strings.ToUpper(i.Company), false)
+	}
+	return nil
+}
+
+var _ = Describe("Hooks", func() {
+	It("calls the hooks of the struct, nested structs and slice elements", func() {
+		input := HookInfo{
+			Company: "hello foods",
+			Brand:   HookBrand{Name: "sabra", Internal: "secret"},
+			Stores:  []HookStore{{Name: "Whole Foods"}},
+		}
+
+		outJSON, err := hummus.Marshal(input)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`
+		{
+			"company": "HELLO FOODS",
+			"brand": {
+				"name": "sabra",
+				"source": "hooks"
+			},
+			"stores": [
+				{
+					"name": "Whole Foods",
+					"slug": "whole-foods"
+				}
+			]
+		}`))
+
+		Expect(input.Stores[0].Slug).To(BeEmpty())
+	})
+
+	It("calls the hooks in MarshalInto and MarshalMerge", func() {
+		outJSON, err := hummus.MarshalInto([]byte(`{"company": "placeholder", "version": "v1"}`), HookInfo{Company: "hello foods"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{"company": "HELLO FOODS", "version": "v1", "brand": {"name": "", "source": "hooks"}, "stores": null}`))
+
+		outJSON, err = hummus.MarshalMerge(HookStore{Name: "Safeway"}, struct {
+			Company string `hummus:"company"`
+		}{Company: "hello foods"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{"name": "Safeway", "slug": "safeway", "company": "hello foods"}`))
+	})
+
+	Context("when a hook fails", func() {
+		It("returns its error", func() {
+			_, err := hummus.Marshal(HookInfo{Stores: []HookStore{{}}})
+			Expect(err).To(MatchError("store without a name"))
+		})
+	})
+})
@@ -1,10 +1,11 @@
 package hummus
 
 import (
-	"errors"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -19,81 +20,496 @@ type arrayTag struct {
 }
 
 type hummusTag struct {
-	tagName   string
-	omitEmpty bool
+	tagName      string
+	omitEmpty    bool
+	required     bool
+	asString     bool
+	hasDefault   bool
+	defaultValue string
+	format       string
+	hasPrecision bool
+	precision    int
+	bytes        string
+	enum         bool
+	hasExpr      bool
+	expr         string
+	hasConst     bool
+	constValue   string
+	redact       bool
+	groups       []string
+	options      map[string]string
+}
+
+type pathOwner struct {
+	path  string
+	input int
 }
 
 func Marshal(input interface{}) ([]byte, error) {
-	t := reflect.TypeOf(input)
-	v := reflect.ValueOf(input)
+	return Tags{}.Marshal(input)
+}
+
+// Marshal is like the package-level Marshal, but reads the chosen tags
+func (tags Tags) Marshal(input interface{}) ([]byte, error) {
+	return MarshalWithOptions(input, Options{Tags: tags})
+}
+
+// MarshalInto marshals input on top of the JSON document in base: values in the
+// struct replace those at the same paths in base (empty ones too, unless their
+// fields are tagged omitempty), nested objects are deep-merged and array
+// indices address the existing elements of base.
+func MarshalInto(base []byte, input interface{}) ([]byte, error) {
+	return Tags{}.MarshalInto(base, input)
+}
+
+// MarshalInto is like the package-level MarshalInto, but reads the chosen tags
+func (tags Tags) MarshalInto(base []byte, input interface{}) ([]byte, error) {
+	flat, err := Flatten(base)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	v, err := beforeHummus(reflect.ValueOf(input))
+	if err != nil {
+		return []byte{}, err
+	}
+
+	parseTree := tree.NewTree()
+	err = insertReflect(parseTree, v.Type(), v, Options{Tags: tags})
+	if err != nil {
+		return []byte{}, err
+	}
+
+	var structPaths []string
+	if obj, ok := parseTree.BuildJSON().Data().(map[string]interface{}); ok {
+		for key, value := range obj {
+			leafPaths(escapeKey(key), value, &structPaths)
+		}
+	}
+
+	var paths []string
+	for path := range flat {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// the template only fills in what the struct doesn't set, and the tree
+	// would otherwise let its values win over the struct's empty ones
+	for _, path := range paths {
+		if collidesWithAny(path, structPaths) {
+			continue
+		}
 
-	jsonObj, err := marshalReflect(t, v)
+		err = parseTree.Insert(fmt.Sprintf("hummus:%q", path), flat[path], false)
+		if _, conflict := err.(*tree.ConflictError); err != nil && !conflict {
+			return []byte{}, err
+		}
+	}
+
+	err = afterHummus(v, &parseTree)
 	if err != nil {
 		return []byte{}, err
 	}
 
-	return []byte(jsonObj.String()), nil
+	return []byte(parseTree.BuildJSON().String()), nil
+}
+
+// MarshalMerge marshals several structs into a single JSON document. Arrays
+// addressed by more than one struct are merged by index, but no two structs may
+// set the same path (or a path inside one set by another).
+func MarshalMerge(inputs ...interface{}) ([]byte, error) {
+	return Tags{}.MarshalMerge(inputs...)
+}
+
+// MarshalMerge is like the package-level MarshalMerge, but reads the chosen
+// tags
+func (tags Tags) MarshalMerge(inputs ...interface{}) ([]byte, error) {
+	parseTree := tree.NewTree()
+	var owners []pathOwner
+
+	for i, input := range inputs {
+		t := reflect.TypeOf(input)
+		v, err := beforeHummus(reflect.ValueOf(input))
+		if err != nil {
+			return []byte{}, err
+		}
+
+		paths := insertedPaths(t, v, tags)
+		for _, path := range paths {
+			for _, owner := range owners {
+				if pathsCollide(path, owner.path) {
+					return []byte{}, fmt.Errorf("error: path %s from input %d collides with path %s from input %d", path, i, owner.path, owner.input)
+				}
+			}
+		}
+
+		for _, path := range paths {
+			owners = append(owners, pathOwner{path: path, input: i})
+		}
+
+		err = insertReflect(parseTree, t, v, Options{Tags: tags})
+		if err != nil {
+			return []byte{}, err
+		}
+
+		err = afterHummus(v, &parseTree)
+		if err != nil {
+			return []byte{}, err
+		}
+	}
+
+	return []byte(parseTree.BuildJSON().String()), nil
 }
 
-func marshalReflect(t reflect.Type, v reflect.Value) (*gabs.Container, error) {
+func marshalReflect(t reflect.Type, v reflect.Value, opts Options) (*gabs.Container, error) {
+	v, err := beforeHummus(v)
+	if err != nil {
+		return nil, err
+	}
+
 	parseTree := tree.NewTree()
-	var err error
 
+	err = insertReflect(parseTree, t, v, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = afterHummus(v, &parseTree)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTree.BuildJSON(), nil
+}
+
+func insertReflect(parseTree tree.Tree, t reflect.Type, v reflect.Value, opts Options) error {
 	for i := 0; i < t.NumField(); i++ {
 		curTypeField := t.Field(i)
 		curValueField := v.Field(i)
 
-		if curValueField.Kind() == reflect.Struct {
-			childJSONObj, err := marshalReflect(reflect.TypeOf(curValueField.Interface()), curValueField)
+		tag, ht, err := opts.Tags.parseField(curTypeField)
+		if err != nil && tag.Get("hummus") != "" {
+			return err
+		}
+
+		if err == nil && opts.excludes(ht) {
+			continue
+		}
+
+		nestedOpts := opts
+		if err == nil {
+			var selected bool
+			selected, nestedOpts = opts.selectField(ht.tagName)
+			if !selected {
+				continue
+			}
+		}
+
+		// computed fields are often blank, so their values are never read
+		if err == nil && ht.isComputed() {
+			var computed interface{}
+			computed, err = computedValue(ht, curTypeField, v)
 			if err != nil {
-				return nil, err
+				return fmt.Errorf("error: field %s: %s", curTypeField.Name, errorText(err))
+			}
+
+			empty := isEmptyValue(reflect.ValueOf(computed))
+			if opts.redacts(ht) && !empty {
+				computed, err = opts.redactValue(computed)
+				if err != nil {
+					return fmt.Errorf("error: field %s: %s", curTypeField.Name, errorText(err))
+				}
 			}
 
-			err = parseTree.Insert(string(curTypeField.Tag), childJSONObj.Data(), isEmptyValue(curValueField))
-		} else if curValueField.Kind() == reflect.Slice && curValueField.Len() > 0 && curValueField.Index(0).Kind() == reflect.Struct {
-			var arrayToMarshal []interface{}
+			err = parseTree.Insert(string(tag), computed, empty)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err == nil && ht.hasDefault && isEmptyValue(curValueField) {
+			curValueField, err = defaultValue(curTypeField, ht.defaultValue)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err == nil && ht.required && isEmptyValue(curValueField) {
+			return fmt.Errorf("error: required field %s (path %s) is empty", curTypeField.Name, ht.tagName)
+		}
+
+		if err == nil && opts.redacts(ht) && !isEmptyValue(curValueField) {
+			var redacted interface{}
+			redacted, err = opts.redactValue(curValueField.Interface())
+			if err != nil {
+				return fmt.Errorf("error: field %s: %s", curTypeField.Name, errorText(err))
+			}
+
+			err = parseTree.Insert(string(tag), redacted, false)
+		} else if err == nil && ht.isFormatted() {
+			var formatted interface{}
+			formatted, err = formatValue(ht, curValueField)
+			if err != nil {
+				return fmt.Errorf("error: field %s: %s", curTypeField.Name, errorText(err))
+			}
+
+			err = parseTree.Insert(string(tag), formatted, isEmptyValue(curValueField))
+		} else if ht.kind(curTypeField.Type) == nestedField {
+			var childJSONObj *gabs.Container
+			childJSONObj, err = marshalReflect(reflect.TypeOf(curValueField.Interface()), curValueField, nestedOpts)
+			if err != nil {
+				return err
+			}
+
+			err = parseTree.Insert(string(tag), childJSONObj.Data(), isEmptyValue(curValueField))
+		} else if ht.kind(curTypeField.Type) == structSliceField && !curValueField.IsNil() {
+			arrayToMarshal := []interface{}{}
 			var childJSONArrayElement *gabs.Container
 
 			for j := 0; j < curValueField.Len(); j++ {
+				selected, elementOpts := nestedOpts.selectField(fmt.Sprintf("[%d]", j))
+				if !selected {
+					arrayToMarshal = append(arrayToMarshal, nil)
+					continue
+				}
+
 				elementToMarshal := curValueField.Index(j).Interface()
-				childJSONArrayElement, err = marshalReflect(reflect.TypeOf(elementToMarshal), curValueField.Index(j))
+				childJSONArrayElement, err = marshalReflect(reflect.TypeOf(elementToMarshal), curValueField.Index(j), elementOpts)
+				if err != nil {
+					return err
+				}
 				arrayToMarshal = append(arrayToMarshal, childJSONArrayElement.Data())
 			}
 
-			err = parseTree.Insert(string(curTypeField.Tag), arrayToMarshal, isEmptyValue(curValueField))
+			// unselected elements only pad the ones after them
+			for len(arrayToMarshal) > 0 && arrayToMarshal[len(arrayToMarshal)-1] == nil {
+				arrayToMarshal = arrayToMarshal[:len(arrayToMarshal)-1]
+			}
+			if len(arrayToMarshal) == 0 && curValueField.Len() > 0 {
+				continue
+			}
+
+			err = parseTree.Insert(string(tag), arrayToMarshal, isEmptyValue(curValueField))
+		} else if ht.asString && quotable(curValueField.Type()) {
+			var quoted interface{}
+			quoted, err = quoteValue(curValueField)
+			if err != nil {
+				return err
+			}
+
+			err = parseTree.Insert(string(tag), quoted, isEmptyValue(curValueField))
 		} else {
-			err = parseTree.Insert(string(curTypeField.Tag), curValueField.Interface(), isEmptyValue(curValueField))
+			err = parseTree.Insert(string(tag), curValueField.Interface(), isEmptyValue(curValueField))
 		}
 
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
-	return parseTree.BuildJSON(), nil
+	return nil
+}
+
+// insertedPaths returns the paths of the fields insertReflect would insert
+func insertedPaths(t reflect.Type, v reflect.Value, tags Tags) []string {
+	var paths []string
+
+	for i := 0; i < t.NumField(); i++ {
+		_, ht, err := tags.parseField(t.Field(i))
+		if err != nil || (ht.omitEmpty && isEmptyValue(v.Field(i))) {
+			continue
+		}
+
+		paths = append(paths, ht.tagName)
+	}
+
+	return paths
+}
+
+// leafPaths appends the paths of the leaves of value, which is at path, to
+// paths. Empty objects and arrays are leaves, but nulls in arrays aren't.
+func leafPaths(path string, value interface{}, paths *[]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			*paths = append(*paths, path)
+		}
+		for key, child := range v {
+			leafPaths(joinPath(path, escapeKey(key)), child, paths)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			*paths = append(*paths, path)
+		}
+		for i, child := range v {
+			// nulls in arrays pad the elements after them
+			if child != nil {
+				leafPaths(fmt.Sprintf("%s[%d]", path, i), child, paths)
+			}
+		}
+	default:
+		*paths = append(*paths, path)
+	}
+}
+
+func collidesWithAny(path string, paths []string) bool {
+	for _, p := range paths {
+		if pathsCollide(path, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pathsCollide reports whether two paths are the same, or one is nested inside
+// the other
+func pathsCollide(a, b string) bool {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+
+	return a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(a, b+"[")
+}
+
+// fieldKind is how the value of a field is laid out in the document
+type fieldKind int
+
+const (
+	// leafField values are written by encoding/json, or by a formatter
+	leafField fieldKind = iota
+
+	// nestedField structs are merged into the document, so their fields are
+	// laid out under the field's path
+	nestedField
+
+	// structSliceField slices have each of their structs marshaled by hummus
+	structSliceField
+)
+
+// kind classifies a field of type t. Every walker of tagged types goes by it,
+// so that they all agree with Marshal.
+func (ht hummusTag) kind(t reflect.Type) fieldKind {
+	if ht.isFormatted() || ht.isComputed() {
+		return leafField
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct:
+		return nestedField
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct:
+		return structSliceField
+	}
+
+	return leafField
+}
+
+func init() {
+	tree.RegisterOption("required", false)
+	tree.RegisterTextOption("default")
+	tree.RegisterOption("string", false)
+	tree.RegisterOption("format", true)
+	tree.RegisterOption("precision", true)
+	tree.RegisterOption("bytes", true)
+	tree.RegisterOption("enum", false)
+	tree.RegisterTextOption("expr")
+	tree.RegisterTextOption("const")
+	tree.RegisterOption("redact", false)
+	tree.RegisterOption("groups", true)
 }
 
 func parseHummusTag(tag reflect.StructTag) (hummusTag, error) {
-	hummusTagString := tag.Get("hummus")
-	if hummusTagString == "" {
-		return hummusTag{}, fmt.Errorf("error: invalid struct tag %s", tag)
+	parsed, err := tree.ParseTag(tag)
+	if err != nil {
+		return hummusTag{}, err
 	}
 
-	tagFields := strings.Split(hummusTagString, ",")
-	var omitEmpty bool
+	ht := hummusTag{
+		tagName:   parsed.Path,
+		omitEmpty: parsed.Has("omitempty"),
+		required:  parsed.Has("required"),
+		asString:  parsed.Has("string"),
+		enum:      parsed.Has("enum"),
+		redact:    parsed.Has("redact"),
+		options:   parsed.Options,
+	}
+	ht.defaultValue, ht.hasDefault = parsed.Options["default"]
+	ht.format = parsed.Options["format"]
+	ht.bytes = parsed.Options["bytes"]
+	ht.expr, ht.hasExpr = parsed.Options["expr"]
+	ht.constValue, ht.hasConst = parsed.Options["const"]
+
+	if precision, ok := parsed.Options["precision"]; ok {
+		ht.hasPrecision = true
+		ht.precision, err = strconv.Atoi(precision)
+		if err != nil || ht.precision < 0 {
+			return hummusTag{}, fmt.Errorf("error: invalid precision %s", precision)
+		}
+	}
 
-	if len(tagFields) > 2 {
-		return hummusTag{}, errors.New("error: invalid number of struct tag fields")
+	if groups, ok := parsed.Options["groups"]; ok {
+		ht.groups, err = parseGroups(groups)
+		if err != nil {
+			return hummusTag{}, err
+		}
 	}
 
-	if len(tagFields) == 2 && tagFields[1] == "omitempty" {
-		omitEmpty = true
+	if _, ok := byteEncodings[ht.bytes]; ht.bytes != "" && !ok {
+		return hummusTag{}, fmt.Errorf("error: unknown bytes encoding %s", ht.bytes)
 	}
 
-	return hummusTag{
-		tagName:   tagFields[0],
-		omitEmpty: omitEmpty,
-	}, nil
+	return ht, nil
+}
+
+// defaultValue parses the default of a field: strings are taken as they are,
+// anything else is decoded as JSON
+func defaultValue(field reflect.StructField, def string) (reflect.Value, error) {
+	v := reflect.New(field.Type).Elem()
+	if field.Type.Kind() == reflect.String {
+		v.SetString(def)
+		return v, nil
+	}
+
+	if err := json.Unmarshal([]byte(def), v.Addr().Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("error: invalid default %s for field %s: %s", def, field.Name, err)
+	}
+
+	return v, nil
+}
+
+// quotable reports whether the string option applies to values of type t,
+// which like in encoding/json are numbers and booleans
+func quotable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// quoteValue returns the JSON encoding of v as a string, or nil for nil pointers
+func quoteValue(v reflect.Value) (interface{}, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
 }
 
 func parseArrayTag(tag string) (arrayTag, bool) {
@@ -121,6 +537,12 @@ func parseArrayTag(tag string) (arrayTag, bool) {
 	return arrayTag{}, false
 }
 
+// errorText returns the message of err without its "error: " prefix, so that it
+// can be wrapped in another error
+func errorText(err error) string {
+	return strings.TrimPrefix(err.Error(), "error: ")
+}
+
 // straight-up stole this from encoding/json
 func isEmptyValue(v reflect.Value) bool {
 	switch v.Kind() {
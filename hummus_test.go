@@ -1,6 +1,10 @@
 package hummus_test
 
 import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
 	"github.com/aditya87/hummus"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -305,6 +309,47 @@ var _ = Describe("Hummus", func() {
 			}`))
 		})
 
+		It("accepts JSON Pointer paths", func() {
+			input := struct {
+				A string `hummus:"/brands/0/name,pointer"`
+				B int    `hummus:"brands[0].stores[1].price"`
+				C string `hummus:"/brands/0/stores/1/name,pointer"`
+				D string `hummus:"/sites/example.com/a~1b,pointer"`
+				E int    `hummus:"/api.v"`
+			}{
+				A: "sabra",
+				B: 10,
+				C: "Safeway",
+				D: "D_val",
+				E: 1,
+			}
+
+			outJSON, err := hummus.Marshal(input)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outJSON).To(MatchJSON(`{
+				"brands": [{
+					"name": "sabra",
+					"stores": [null, {"name": "Safeway", "price": 10}]
+				}],
+				"sites": {
+					"example.com": {"a/b": "D_val"}
+				},
+				"/api": {"v": 1}
+			}`))
+		})
+
+		It("rejects invalid JSON Pointer paths", func() {
+			_, err := hummus.Marshal(struct {
+				A string `hummus:"/brands//name,pointer"`
+			}{})
+			Expect(err).To(MatchError(`error: invalid JSON pointer token "" in /brands//name`))
+
+			_, err = hummus.Marshal(struct {
+				A string `hummus:"brands.name,pointer"`
+			}{})
+			Expect(err).To(MatchError("error: invalid JSON pointer brands.name"))
+		})
+
 		It("marshals nested structs", func() {
 			type Inner struct {
 				A string `hummus:"innerchild.fieldA"`
@@ -404,6 +449,110 @@ var _ = Describe("Hummus", func() {
 			}`))
 		})
 
+		It("deep-merges nested structs with flat paths", func() {
+			type Brand struct {
+				Name   string `hummus:"name"`
+				Flavor string `hummus:"flavor"`
+			}
+
+			type Store struct {
+				Name string `hummus:"name"`
+			}
+
+			input := struct {
+				B          Brand   `hummus:"brand"`
+				Extra      string  `hummus:"brand.extra"`
+				Flavor     string  `hummus:"brand.flavor"`
+				S          []Store `hummus:"stores"`
+				Store1Addr string  `hummus:"stores[1].address"`
+			}{
+				B:          Brand{Name: "sabra"},
+				Extra:      "spicy",
+				Flavor:     "jalapeno",
+				S:          []Store{{Name: "safeway"}, {Name: "wholefoods"}},
+				Store1Addr: "338 New St",
+			}
+
+			// the tree used to be built in random map order, so check more than once
+			for i := 0; i < 20; i++ {
+				outJSON, err := hummus.Marshal(input)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(outJSON).To(MatchJSON(`
+				{
+					"brand": {
+						"name": "sabra",
+						"flavor": "jalapeno",
+						"extra": "spicy"
+					},
+					"stores": [
+						{
+							"name": "safeway"
+						},
+						{
+							"name": "wholefoods",
+							"address": "338 New St"
+						}
+					]
+				}`))
+			}
+		})
+
+		It("fills in defaults for empty fields", func() {
+			input := struct {
+				Amount   float64 `hummus:"order.amount"`
+				Currency string  `hummus:"order.currency,default=USD"`
+				Quantity int     `hummus:"order.quantity,default=1"`
+				Express  bool    `hummus:"order.express,default=true"`
+			}{
+				Amount:   9.99,
+				Quantity: 3,
+			}
+
+			outJSON, err := hummus.Marshal(input)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outJSON).To(MatchJSON(`
+			{
+				"order": {
+					"amount": 9.99,
+					"currency": "USD",
+					"quantity": 3,
+					"express": true
+				}
+			}`))
+		})
+
+		It("writes numbers and booleans as strings with the string option", func() {
+			id := int64(9007199254740993)
+			input := struct {
+				ID      int64   `hummus:"order.id,string"`
+				Amount  float64 `hummus:"order.amount,string"`
+				Express bool    `hummus:"order.express,string"`
+				Ref     *int64  `hummus:"order.ref,string"`
+				Missing *int64  `hummus:"order.missing,string"`
+				Note    string  `hummus:"order.note,string"`
+			}{
+				ID:      id,
+				Amount:  9.99,
+				Express: true,
+				Ref:     &id,
+				Note:    "fragile",
+			}
+
+			outJSON, err := hummus.Marshal(input)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outJSON).To(MatchJSON(`
+			{
+				"order": {
+					"id": "9007199254740993",
+					"amount": "9.99",
+					"express": "true",
+					"ref": "9007199254740993",
+					"missing": null,
+					"note": "fragile"
+				}
+			}`))
+		})
+
 		Context("special/failure cases", func() {
 			Context("when passed an invalid struct tag", func() {
 				It("skips the field", func() {
@@ -419,7 +568,51 @@ var _ = Describe("Hummus", func() {
 				})
 			})
 
-			Context("when passed extra struct tag fields", func() {
+			Context("when two fields set the same path to different values", func() {
+				It("returns an error", func() {
+					type Brand struct {
+						Name string `hummus:"name"`
+					}
+
+					input := struct {
+						B    Brand  `hummus:"brand"`
+						Name string `hummus:"brand.name"`
+					}{
+						B:    Brand{Name: "sabra"},
+						Name: "cedars",
+					}
+
+					_, err := hummus.Marshal(input)
+					Expect(err).To(MatchError("error: conflicting values for path brand.name"))
+				})
+			})
+
+			Context("when a required field is empty", func() {
+				It("returns an error naming the field", func() {
+					input := struct {
+						Name       string `hummus:"customer.name"`
+						CustomerID string `hummus:"customer.id,required"`
+					}{
+						Name: "sabra",
+					}
+
+					_, err := hummus.Marshal(input)
+					Expect(err).To(MatchError("error: required field CustomerID (path customer.id) is empty"))
+				})
+			})
+
+			Context("when a default can't be decoded", func() {
+				It("returns an error", func() {
+					input := struct {
+						Quantity int `hummus:"quantity,default=one"`
+					}{}
+
+					_, err := hummus.Marshal(input)
+					Expect(err).To(MatchError(ContainSubstring("error: invalid default one for field Quantity")))
+				})
+			})
+
+			Context("when passed an unknown struct tag option", func() {
 				It("returns an error", func() {
 					input := struct {
 						Brand0 string `hummus:"safeway.brands[0],omitempty,blah"`
@@ -428,9 +621,248 @@ var _ = Describe("Hummus", func() {
 					}
 
 					_, err := hummus.Marshal(input)
-					Expect(err).To(MatchError("error: invalid number of struct tag fields"))
+					Expect(err).To(MatchError("error: unknown struct tag option blah"))
 				})
 			})
 		})
 	})
+	Describe("MarshalInto", func() {
+		base := []byte(`{
+			"version": "v1",
+			"company": "placeholder",
+			"brands": [
+				{
+					"name": "sabra",
+					"stores": [
+						{"name": "safeway"},
+						{"name": "wholefoods"}
+					]
+				},
+				{
+					"name": "cedars"
+				}
+			],
+			"reputation": {
+				"type": "unknown",
+				"source": "survey"
+			}
+		}`)
+
+		It("overlays struct values on top of the template", func() {
+			input := struct {
+				Company           string `hummus:"company"`
+				Brand0Store1Price int    `hummus:"brands[0].stores[1].price"`
+				Brand1Flavor      string `hummus:"brands[1].flavor"`
+			}{
+				Company:           "hello foods",
+				Brand0Store1Price: 10,
+				Brand1Flavor:      "garlic",
+			}
+
+			outJSON, err := hummus.MarshalInto(base, input)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outJSON).To(MatchJSON(`{
+				"version": "v1",
+				"company": "hello foods",
+				"brands": [
+					{
+						"name": "sabra",
+						"stores": [
+							{"name": "safeway"},
+							{"name": "wholefoods", "price": 10}
+						]
+					},
+					{
+						"name": "cedars",
+						"flavor": "garlic"
+					}
+				],
+				"reputation": {
+					"type": "unknown",
+					"source": "survey"
+				}
+			}`))
+		})
+
+		It("deep-merges nested structs into the template", func() {
+			type Reputation struct {
+				Type  string `hummus:"type"`
+				Score int    `hummus:"score"`
+			}
+
+			input := struct {
+				R Reputation `hummus:"reputation"`
+			}{
+				R: Reputation{
+					Type:  "good",
+					Score: 9,
+				},
+			}
+
+			outJSON, err := hummus.MarshalInto(base, input)
+			Expect(err).NotTo(HaveOccurred())
+
+			reputation, err := hummus.Get(outJSON, "reputation")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reputation).To(Equal(map[string]interface{}{
+				"type":   "good",
+				"score":  json.Number("9"),
+				"source": "survey",
+			}))
+		})
+
+		It("overrides template values with empty struct values", func() {
+			input := struct {
+				Discount int    `hummus:"discount"`
+				Active   bool   `hummus:"active"`
+				Type     string `hummus:"reputation.type"`
+				Note     string `hummus:"note,omitempty"`
+			}{}
+
+			outJSON, err := hummus.MarshalInto([]byte(`{
+				"discount": 10,
+				"active": true,
+				"note": "keep",
+				"reputation": {"type": "unknown", "source": "survey"}
+			}`), input)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outJSON).To(MatchJSON(`{
+				"discount": 0,
+				"active": false,
+				"note": "keep",
+				"reputation": {"type": "", "source": "survey"}
+			}`))
+		})
+
+		It("leaves out omitted empty fields", func() {
+			input := struct {
+				Company string `hummus:"company,omitempty"`
+			}{}
+
+			outJSON, err := hummus.MarshalInto(base, input)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outJSON).To(MatchJSON(base))
+		})
+
+		Context("when passed an invalid template", func() {
+			It("returns an error", func() {
+				_, err := hummus.MarshalInto([]byte(`["sabra"]`), struct{}{})
+				Expect(err).To(MatchError("error: can only flatten JSON objects"))
+			})
+		})
+	})
+	Describe("MarshalMerge", func() {
+		type RequestContext struct {
+			RequestID string `hummus:"meta.request_id"`
+		}
+
+		type User struct {
+			Name  string `hummus:"user.name"`
+			Email string `hummus:"user.email,omitempty"`
+		}
+
+		type Order struct {
+			Item0 string `hummus:"items[0].sku"`
+			Item1 string `hummus:"items[1].sku"`
+		}
+
+		It("merges several structs into one document", func() {
+			outJSON, err := hummus.MarshalMerge(
+				RequestContext{RequestID: "abc"},
+				User{Name: "sabra"},
+				Order{Item0: "hummus-1", Item1: "hummus-2"},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outJSON).To(MatchJSON(`{
+				"meta": {
+					"request_id": "abc"
+				},
+				"user": {
+					"name": "sabra"
+				},
+				"items": [
+					{"sku": "hummus-1"},
+					{"sku": "hummus-2"}
+				]
+			}`))
+		})
+
+		It("merges arrays targeted by different structs by index", func() {
+			first := struct {
+				Item0 string `hummus:"items[0].sku"`
+			}{"hummus-1"}
+
+			second := struct {
+				Item1 string `hummus:"items[1].sku"`
+				Qty0  int    `hummus:"items[0].qty"`
+			}{"hummus-2", 3}
+
+			outJSON, err := hummus.MarshalMerge(first, second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outJSON).To(MatchJSON(`{
+				"items": [
+					{"sku": "hummus-1", "qty": 3},
+					{"sku": "hummus-2"}
+				]
+			}`))
+		})
+
+		Context("when two structs set the same path", func() {
+			It("returns an error", func() {
+				other := struct {
+					Name string `hummus:"user.name"`
+				}{"cedars"}
+
+				_, err := hummus.MarshalMerge(User{Name: "sabra"}, other)
+				Expect(err).To(MatchError("error: path user.name from input 1 collides with path user.name from input 0"))
+			})
+		})
+
+		Context("when a struct sets a path inside one set by another", func() {
+			It("returns an error", func() {
+				other := struct {
+					Items []string `hummus:"items"`
+				}{[]string{"hummus-3"}}
+
+				_, err := hummus.MarshalMerge(Order{Item0: "hummus-1"}, other)
+				Expect(err).To(MatchError("error: path items from input 1 collides with path items[0].sku from input 0"))
+			})
+		})
+
+		Context("when an omitted empty field shares a path", func() {
+			It("does not report a collision", func() {
+				other := struct {
+					Email string `hummus:"user.email"`
+				}{"sabra@example.com"}
+
+				outJSON, err := hummus.MarshalMerge(User{Name: "sabra"}, other)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(outJSON).To(MatchJSON(`{
+					"user": {
+						"name": "sabra",
+						"email": "sabra@example.com"
+					}
+				}`))
+			})
+		})
+	})
 })
+
+func BenchmarkMarshalMap(b *testing.B) {
+	for _, size := range []int{1000, 16000} {
+		input := struct {
+			Counts map[string]interface{} `hummus:"stats.counts"`
+		}{Counts: make(map[string]interface{})}
+		for i := 0; i < size; i++ {
+			input.Counts[fmt.Sprintf("key%d", i)] = i
+		}
+
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := hummus.Marshal(input); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package hummus
+
+import "github.com/aditya87/hummus/tree"
+
+// Nest builds a JSON document from values keyed by hummus paths, e.g.
+// "brands[0].stores[1].price", following the same rules as Marshal.
+func Nest(m map[string]interface{}) ([]byte, error) {
+	parseTree, err := tree.FromMap(m)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return []byte(parseTree.BuildJSON().String()), nil
+}
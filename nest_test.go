@@ -0,0 +1,110 @@
+package hummus_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Nest", func() {
+	It("builds nested JSON from a flat map of paths", func() {
+		outJSON, err := hummus.Nest(map[string]interface{}{
+			"company":                  "hello foods",
+			"brands[0].name":           "sabra",
+			"brands[0].stores[0].name": "safeway",
+			"brands[0].stores[1].name": "wholefoods",
+			"brands[1].name":           "cedars",
+			"reputation.type":          "good",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{
+			"company": "hello foods",
+			"brands": [
+				{
+					"name": "sabra",
+					"stores": [
+						{
+							"name": "safeway"
+						},
+						{
+							"name": "wholefoods"
+						}
+					]
+				},
+				{
+					"name": "cedars"
+				}
+			],
+			"reputation": {
+				"type": "good"
+			}
+		}`))
+	})
+
+	It("allows one to escape dots", func() {
+		outJSON, err := hummus.Nest(map[string]interface{}{
+			"outer.inner#notchild.name": "A_val",
+			"outer.inner":               "B_val",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{
+			"outer": {
+				"inner.notchild": {
+					"name": "A_val"
+				},
+				"inner": "B_val"
+			}
+		}`))
+	})
+
+	It("fills gaps in sparse arrays with nulls", func() {
+		outJSON, err := hummus.Nest(map[string]interface{}{
+			"brands[2]": "sabra",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{
+			"brands": [null, null, "sabra"]
+		}`))
+	})
+
+	Context("special/failure cases", func() {
+		Context("when a path is empty", func() {
+			It("returns an error", func() {
+				_, err := hummus.Nest(map[string]interface{}{
+					"": "sabra",
+				})
+				Expect(err).To(MatchError("error: empty path"))
+			})
+		})
+
+		Context("when an array element is both a value and an object", func() {
+			It("returns an error", func() {
+				_, err := hummus.Nest(map[string]interface{}{
+					"brands[0]":      "sabra",
+					"brands[0].name": "sabra",
+				})
+				Expect(err).To(MatchError("error: conflicting values for path brands[0].name"))
+			})
+		})
+	})
+})
+
+func BenchmarkNest(b *testing.B) {
+	for _, size := range []int{1000, 16000} {
+		m := make(map[string]interface{})
+		for i := 0; i < size; i++ {
+			m[fmt.Sprintf("stats.key%d", i)] = i
+		}
+
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := hummus.Nest(m); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package hummus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const redactedMask = "[REDACTED]"
+
+// Options tailor a document to its audience.
+type Options struct {
+	// Groups selects the fields tagged with a groups option, e.g.
+	// hummus:"internal.cost,groups=admin|support". Fields without the option
+	// are always written, and no groups means every field is written.
+	Groups []string
+
+	// Fields selects the paths written, like MarshalFields does.
+	Fields []string
+
+	// Redact replaces the values of fields tagged with the redact option.
+	Redact bool
+
+	// Redactor returns the value written in place of a redacted one. Without
+	// it, redacted values are written as "[REDACTED]".
+	Redactor Formatter
+
+	// Tags chooses the struct tags read.
+	Tags Tags
+}
+
+// Tags chooses the struct tags that hummus reads. Its methods are the
+// package-level functions of the same names, which read the hummus tags of the
+// zero Tags.
+type Tags struct {
+	// Key is the key of the struct tags read instead of hummus, e.g. hummusv2,
+	// so that one struct can be mapped to several documents.
+	Key string
+
+	// JSONFallback reads the json tag of fields without a tag of their own as
+	// a flat path, keeping its omitempty and string options.
+	JSONFallback bool
+}
+
+// MarshalWithOptions is like Marshal, but drops the fields that aren't selected
+// by Fields or Groups, redacts fields tagged with the redact option and reads
+// the tags chosen by Tags.
+func MarshalWithOptions(input interface{}, opts Options) ([]byte, error) {
+	jsonObj, err := marshalReflect(reflect.TypeOf(input), reflect.ValueOf(input), opts)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return []byte(jsonObj.String()), nil
+}
+
+// HashRedactor is a Redactor that writes the SHA-256 hash of the JSON encoding
+// of a value, so that redacted values can still be compared.
+func HashRedactor(value interface{}) (interface{}, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// excludes reports whether a field is left out of the selected groups
+func (opts Options) excludes(ht hummusTag) bool {
+	if len(opts.Groups) == 0 || len(ht.groups) == 0 {
+		return false
+	}
+
+	for _, group := range ht.groups {
+		for _, selected := range opts.Groups {
+			if group == selected {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// redacts reports whether the value of a field is replaced
+func (opts Options) redacts(ht hummusTag) bool {
+	return opts.Redact && ht.redact
+}
+
+// redactValue returns the value written in place of a redacted one
+func (opts Options) redactValue(value interface{}) (interface{}, error) {
+	if opts.Redactor == nil {
+		return redactedMask, nil
+	}
+
+	return opts.Redactor(value)
+}
+
+// parseGroups splits the value of a groups option
+func parseGroups(groups string) ([]string, error) {
+	parsed := strings.Split(groups, "|")
+	for _, group := range parsed {
+		if group == "" {
+			return nil, fmt.Errorf("error: invalid groups %s", groups)
+		}
+	}
+
+	return parsed, nil
+}
+
+// parseField parses the tag of a field, and also returns it in the form
+// tree.Insert reads. Fields without a tag are reported with an empty tag.
+func (tags Tags) parseField(field reflect.StructField) (reflect.StructTag, hummusTag, error) {
+	tag := tags.fieldTag(field)
+	ht, err := parseHummusTag(tag)
+	return tag, ht, err
+}
+
+// fieldTag returns the tag of a field in the form parseHummusTag and
+// tree.Insert read, taking it from the tag chosen by Key or, failing that, the
+// json tag when JSONFallback is set
+func (tags Tags) fieldTag(field reflect.StructField) reflect.StructTag {
+	if tags.Key == "" || tags.Key == "hummus" {
+		if field.Tag.Get("hummus") != "" || !tags.JSONFallback {
+			return field.Tag
+		}
+	} else if tag := field.Tag.Get(tags.Key); tag != "" {
+		return reflect.StructTag(fmt.Sprintf("hummus:%q", tag))
+	}
+
+	if !tags.JSONFallback {
+		return ""
+	}
+
+	return jsonFieldTag(field)
+}
+
+// jsonFieldTag converts the json tag of a field into a hummus tag with a flat
+// path, or returns an empty tag if the field has no json tag or is skipped
+func jsonFieldTag(field reflect.StructField) reflect.StructTag {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok || tag == "-" {
+		return ""
+	}
+
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	value := strings.Replace(name, ".", "#", -1)
+	for _, option := range parts[1:] {
+		if option == "omitempty" || option == "string" {
+			value += "," + option
+		}
+	}
+
+	return reflect.StructTag(fmt.Sprintf("hummus:%q", value))
+}
@@ -0,0 +1,197 @@
+package hummus_test
+
+import (
+	"reflect"
+
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type OptionsCustomer struct {
+	Name string `hummus:"name"`
+	SSN  string `hummus:"ssn,redact"`
+}
+
+type OptionsOrder struct {
+	ID        string            `hummus:"order.id"`
+	Cost      float64           `hummus:"internal.cost,groups=admin"`
+	Notes     string            `hummus:"internal.notes,groups=admin|support,omitempty"`
+	Customer  OptionsCustomer   `hummus:"customer"`
+	Customers []OptionsCustomer `hummus:"customers"`
+	Card      *string           `hummus:"card,redact"`
+}
+
+var _ = Describe("MarshalWithOptions", func() {
+	order := OptionsOrder{
+		ID:        "o-1",
+		Cost:      12.5,
+		Notes:     "call first",
+		Customer:  OptionsCustomer{Name: "Alice", SSN: "123-45-6789"},
+		Customers: []OptionsCustomer{{Name: "Bob", SSN: "987-65-4321"}},
+	}
+
+	It("writes every field without options, like Marshal", func() {
+		outJSON, err := hummus.MarshalWithOptions(order, hummus.Options{})
+		Expect(err).NotTo(HaveOccurred())
+
+		marshaled, err := hummus.Marshal(order)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(marshaled))
+	})
+
+	It("drops the fields that aren't in the selected groups", func() {
+		outJSON, err := hummus.MarshalWithOptions(order, hummus.Options{Groups: []string{"public"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`
+		{
+			"order": {"id": "o-1"},
+			"customer": {"name": "Alice", "ssn": "123-45-6789"},
+			"customers": [{"name": "Bob", "ssn": "987-65-4321"}],
+			"card": null
+		}`))
+
+		outJSON, err = hummus.MarshalWithOptions(order, hummus.Options{Groups: []string{"support"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`
+		{
+			"order": {"id": "o-1"},
+			"internal": {"notes": "call first"},
+			"customer": {"name": "Alice", "ssn": "123-45-6789"},
+			"customers": [{"name": "Bob", "ssn": "987-65-4321"}],
+			"card": null
+		}`))
+	})
+
+	It("redacts fields in nested structs and slice elements, but leaves empty ones alone", func() {
+		outJSON, err := hummus.MarshalWithOptions(order, hummus.Options{Groups: []string{"admin"}, Redact: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`
+		{
+			"order": {"id": "o-1"},
+			"internal": {"cost": 12.5, "notes": "call first"},
+			"customer": {"name": "Alice", "ssn": "[REDACTED]"},
+			"customers": [{"name": "Bob", "ssn": "[REDACTED]"}],
+			"card": null
+		}`))
+	})
+
+	It("redacts with a custom redactor", func() {
+		outJSON, err := hummus.MarshalWithOptions(OptionsCustomer{Name: "Alice", SSN: "123-45-6789"}, hummus.Options{
+			Redact:   true,
+			Redactor: hummus.HashRedactor,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`
+		{
+			"name": "Alice",
+			"ssn": "sha256:4b6f8243a6b470a0d1db96a50cfd5a694b4a7bdff2c3b55890c9afadd67b4cf3"
+		}`))
+	})
+
+	Context("when a groups option is invalid", func() {
+		It("returns an error", func() {
+			_, err := hummus.MarshalWithOptions(struct {
+				Cost float64 `hummus:"cost,groups=admin||support"`
+			}{}, hummus.Options{})
+			Expect(err).To(MatchError("error: invalid groups admin||support"))
+		})
+	})
+})
+
+type TagKeyBrand struct {
+	Name string `hummus:"brand.name" hummusv2:"name" json:"name"`
+}
+
+type TagKeyInfo struct {
+	Company string      `hummus:"company" hummusv2:"org.name" json:"company"`
+	Domain  string      `json:"example.com,omitempty"`
+	Count   int         `json:"count,string"`
+	Brand   TagKeyBrand `hummusv2:"brand" json:"brand"`
+	Secret  string      `json:"-"`
+	Plain   string
+}
+
+var _ = Describe("Tags", func() {
+	info := TagKeyInfo{
+		Company: "hello foods",
+		Count:   3,
+		Brand:   TagKeyBrand{Name: "sabra"},
+		Secret:  "shh",
+		Plain:   "plain",
+	}
+
+	It("reads the tags chosen by Key", func() {
+		tags := hummus.Tags{Key: "hummusv2"}
+		outJSON, err := tags.Marshal(info)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{"org": {"name": "hello foods"}, "brand": {"name": "sabra"}}`))
+
+		var output TagKeyInfo
+		Expect(tags.Unmarshal(outJSON, &output)).To(Succeed())
+		Expect(output).To(Equal(TagKeyInfo{Company: "hello foods", Brand: TagKeyBrand{Name: "sabra"}}))
+	})
+
+	It("falls back to json tags as flat paths", func() {
+		outJSON, err := hummus.Tags{JSONFallback: true}.Marshal(info)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{"company": "hello foods", "count": "3", "brand": {"brand": {"name": "sabra"}}}`))
+
+		tags := hummus.Tags{Key: "hummusv2", JSONFallback: true}
+		outJSON, err = tags.Marshal(TagKeyInfo{Domain: "hello.example.com"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{"org": {"name": ""}, "example.com": "hello.example.com", "count": "0", "brand": {"name": ""}}`))
+
+		var output TagKeyInfo
+		Expect(tags.Unmarshal(outJSON, &output)).To(Succeed())
+		Expect(output).To(Equal(TagKeyInfo{Domain: "hello.example.com"}))
+	})
+
+	It("are read by MarshalWithOptions", func() {
+		outJSON, err := hummus.MarshalWithOptions(info, hummus.Options{
+			Tags:   hummus.Tags{Key: "hummusv2"},
+			Fields: []string{"brand"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{"brand": {"name": "sabra"}}`))
+	})
+
+	It("are read by every other function", func() {
+		tags := hummus.Tags{Key: "hummusv2"}
+		changed := info
+		changed.Company = "tribe"
+
+		patch, err := tags.MergePatch(info, changed)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(patch).To(MatchJSON(`{"org": {"name": "tribe"}}`))
+
+		patch, err = tags.JSONPatch(info, changed)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(patch).To(MatchJSON(`[{"op": "replace", "path": "/org/name", "value": "tribe"}]`))
+
+		output := info
+		Expect(tags.ApplyPatch(&output, []byte(`{"org": {"name": "tribe"}}`))).To(Succeed())
+		Expect(output).To(Equal(changed))
+
+		outJSON, err := tags.MarshalInto([]byte(`{"org": {"id": 1}}`), info)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{"org": {"id": 1, "name": "hello foods"}, "brand": {"name": "sabra"}}`))
+
+		_, err = tags.MarshalMerge(info, TagKeyBrand{Name: "cedars"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = tags.MarshalMerge(info, info)
+		Expect(err).To(MatchError("error: path org.name from input 1 collides with path org.name from input 0"))
+
+		Expect(tags.Check(TagKeyInfo{})).To(Succeed())
+		Expect(tags.Explain(reflect.TypeOf(info))).To(ContainSubstring(`"org": {`))
+		Expect(tags.ExplainValue(info)).To(ContainSubstring(`"name": Company string = "hello foods"`))
+
+		schema, err := tags.Schema(reflect.TypeOf(info))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(schema)).To(ContainSubstring(`"org":{`))
+
+		ts, err := tags.TypeScript(reflect.TypeOf(info))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ts).To(ContainSubstring("org: {"))
+	})
+})
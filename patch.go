@@ -0,0 +1,660 @@
+package hummus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MergePatch returns the RFC 7396 JSON Merge Patch that turns the document
+// marshaled from old into the one marshaled from new. Arrays are replaced
+// whole, and since null removes a key in a merge patch, values that become
+// null in new are removed rather than set to null.
+func MergePatch(old, new interface{}) ([]byte, error) {
+	return Tags{}.MergePatch(old, new)
+}
+
+// MergePatch is like the package-level MergePatch, but marshals the chosen
+// tags
+func (tags Tags) MergePatch(old, new interface{}) ([]byte, error) {
+	oldDoc, newDoc, err := tags.marshalDocs(old, new)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return json.Marshal(mergePatch(oldDoc, newDoc))
+}
+
+// JSONPatch returns the RFC 6902 JSON Patch operations that turn the document
+// marshaled from old into the one marshaled from new. Array elements are
+// compared by index, with elements added to or removed from the end.
+func JSONPatch(old, new interface{}) ([]byte, error) {
+	return Tags{}.JSONPatch(old, new)
+}
+
+// JSONPatch is like the package-level JSONPatch, but marshals the chosen tags
+func (tags Tags) JSONPatch(old, new interface{}) ([]byte, error) {
+	oldDoc, newDoc, err := tags.marshalDocs(old, new)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	ops := []map[string]interface{}{}
+	jsonPatch(&ops, "", oldDoc, newDoc)
+
+	return json.Marshal(ops)
+}
+
+func (tags Tags) marshalDocs(old, new interface{}) (interface{}, interface{}, error) {
+	var docs []interface{}
+	for _, input := range []interface{}{old, new} {
+		data, err := tags.Marshal(input)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		doc, err := decodeJSON(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs[0], docs[1], nil
+}
+
+func mergePatch(old, new interface{}) interface{} {
+	oldObj, oldIsObj := old.(map[string]interface{})
+	newObj, newIsObj := new.(map[string]interface{})
+	if !oldIsObj || !newIsObj {
+		return new
+	}
+
+	patch := make(map[string]interface{})
+	for key := range oldObj {
+		if _, ok := newObj[key]; !ok {
+			patch[key] = nil
+		}
+	}
+
+	for key, newValue := range newObj {
+		oldValue, ok := oldObj[key]
+		if ok && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		if ok {
+			patch[key] = mergePatch(oldValue, newValue)
+		} else {
+			patch[key] = newValue
+		}
+	}
+
+	return patch
+}
+
+func jsonPatch(ops *[]map[string]interface{}, path string, old, new interface{}) {
+	if reflect.DeepEqual(old, new) {
+		return
+	}
+
+	oldObj, oldIsObj := old.(map[string]interface{})
+	newObj, newIsObj := new.(map[string]interface{})
+	if oldIsObj && newIsObj {
+		var keys []string
+		for key := range oldObj {
+			keys = append(keys, key)
+		}
+		for key := range newObj {
+			if _, ok := oldObj[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			oldValue, inOld := oldObj[key]
+			newValue, inNew := newObj[key]
+			keyPath := path + "/" + escapePointer(key)
+
+			switch {
+			case !inNew:
+				*ops = append(*ops, map[string]interface{}{"op": "remove", "path": keyPath})
+			case !inOld:
+				*ops = append(*ops, map[string]interface{}{"op": "add", "path": keyPath, "value": newValue})
+			default:
+				jsonPatch(ops, keyPath, oldValue, newValue)
+			}
+		}
+		return
+	}
+
+	oldArray, oldIsArray := old.([]interface{})
+	newArray, newIsArray := new.([]interface{})
+	if oldIsArray && newIsArray {
+		for i := 0; i < len(oldArray) && i < len(newArray); i++ {
+			jsonPatch(ops, path+"/"+strconv.Itoa(i), oldArray[i], newArray[i])
+		}
+
+		for i := len(oldArray); i < len(newArray); i++ {
+			*ops = append(*ops, map[string]interface{}{"op": "add", "path": path + "/" + strconv.Itoa(i), "value": newArray[i]})
+		}
+
+		// removing from the end keeps the indices of the other elements
+		for i := len(oldArray) - 1; i >= len(newArray); i-- {
+			*ops = append(*ops, map[string]interface{}{"op": "remove", "path": path + "/" + strconv.Itoa(i)})
+		}
+		return
+	}
+
+	*ops = append(*ops, map[string]interface{}{"op": "replace", "path": path, "value": new})
+}
+
+// escapePointer escapes a key for use in a JSON Pointer
+func escapePointer(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ApplyPatch applies an RFC 6902 JSON Patch (a JSON array) or an RFC 7396 JSON
+// Merge Patch (a JSON object) to the document the struct v points to marshals
+// to, and stores the values at the patched paths back in their fields. It
+// fails without changing v if a patched path has no field mapped to it, or
+// one with a custom format.
+func ApplyPatch(v interface{}, patch []byte) error {
+	return Tags{}.ApplyPatch(v, patch)
+}
+
+// ApplyPatch is like the package-level ApplyPatch, but patches the fields of
+// the chosen tags
+func (tags Tags) ApplyPatch(v interface{}, patch []byte) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("error: can only apply a patch to a pointer to a struct")
+	}
+
+	data, err := tags.Marshal(rv.Elem().Interface())
+	if err != nil {
+		return err
+	}
+
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return err
+	}
+
+	var patched []string
+	if trimmed := bytes.TrimSpace(patch); len(trimmed) > 0 && trimmed[0] == '[' {
+		doc, patched, err = applyJSONPatch(doc, patch)
+	} else {
+		doc, patched, err = applyMergePatch(doc, patch)
+	}
+	if err != nil {
+		return err
+	}
+
+	mapped := mappedPaths(rv.Elem().Type(), "", tags)
+	for _, path := range patched {
+		if !isMapped(path, mapped) {
+			return fmt.Errorf("error: no field is mapped to path %s", path)
+		}
+	}
+
+	// patch a copy, so that v is left alone if a value can't be stored
+	c := reflect.New(rv.Elem().Type()).Elem()
+	c.Set(rv.Elem())
+	if err := applyFields(doc, c, "", patched, tags); err != nil {
+		return err
+	}
+
+	rv.Elem().Set(c)
+	return nil
+}
+
+// applyJSONPatch applies the operations of a JSON Patch to doc, and returns
+// the hummus paths they change
+func applyJSONPatch(doc interface{}, patch []byte) (interface{}, []string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	decoder.UseNumber()
+
+	var ops []patchOperation
+	if err := decoder.Decode(&ops); err != nil {
+		return nil, nil, err
+	}
+
+	var patched []string
+	for _, op := range ops {
+		tokens, err := pointerTokens(op.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var value interface{}
+		switch op.Op {
+		case "add", "replace", "test":
+			if len(op.Value) == 0 {
+				return nil, nil, fmt.Errorf("error: %s operation at %s has no value", op.Op, op.Path)
+			}
+
+			value, err = decodeJSON(op.Value)
+			if err != nil {
+				return nil, nil, err
+			}
+		case "move", "copy":
+			fromTokens, err := pointerTokens(op.From)
+			if err != nil {
+				return nil, nil, err
+			}
+			if op.Op == "move" && strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, nil, fmt.Errorf("error: cannot move %s into itself", op.From)
+			}
+
+			var ok bool
+			value, ok = getPointer(doc, fromTokens)
+			if !ok {
+				return nil, nil, fmt.Errorf("error: path %s not found", op.From)
+			}
+
+			if op.Op == "move" {
+				patched = append(patched, changedPath(doc, fromTokens))
+				doc, _ = removePointer(doc, fromTokens)
+			} else {
+				// copies mustn't share objects with the originals
+				b, err := json.Marshal(value)
+				if err != nil {
+					return nil, nil, err
+				}
+				value, _ = decodeJSON(b)
+			}
+		case "remove":
+		default:
+			return nil, nil, fmt.Errorf("error: unknown patch operation %s", op.Op)
+		}
+
+		var ok bool
+		switch op.Op {
+		case "test":
+			current, found := getPointer(doc, tokens)
+			if !found || !reflect.DeepEqual(current, value) {
+				return nil, nil, fmt.Errorf("error: test of %s failed", op.Path)
+			}
+			continue
+		case "remove":
+			patched = append(patched, changedPath(doc, tokens))
+			doc, ok = removePointer(doc, tokens)
+		case "replace":
+			patched = append(patched, pointerPath(doc, tokens))
+			patched = append(patched, valuePaths(pointerPath(doc, tokens), value)...)
+			doc, ok = removePointer(doc, tokens)
+			if ok {
+				doc, ok = addPointer(doc, tokens, value)
+			}
+		default:
+			patched = append(patched, changedPath(doc, tokens))
+			patched = append(patched, valuePaths(pointerPath(doc, tokens), value)...)
+			doc, ok = addPointer(doc, tokens, value)
+		}
+		if !ok {
+			return nil, nil, fmt.Errorf("error: cannot %s %s", op.Op, op.Path)
+		}
+	}
+
+	return doc, patched, nil
+}
+
+// applyMergePatch applies a JSON Merge Patch to doc, and returns the hummus
+// paths it changes
+func applyMergePatch(doc interface{}, patch []byte) (interface{}, []string, error) {
+	p, err := decodeJSON(patch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var patched []string
+	return mergeInto(doc, p, "", &patched), patched, nil
+}
+
+func mergeInto(target, patch interface{}, path string, patched *[]string) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		*patched = append(*patched, path)
+		*patched = append(*patched, valuePaths(path, patch)...)
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		// a new object replaces whatever was at path
+		*patched = append(*patched, path)
+		targetObj = make(map[string]interface{})
+	}
+
+	var keys []string
+	for key := range patchObj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := patchObj[key]
+		keyPath := joinPath(path, escapeKey(key))
+		if value == nil {
+			*patched = append(*patched, keyPath)
+			delete(targetObj, key)
+			continue
+		}
+
+		targetObj[key] = mergeInto(targetObj[key], value, keyPath, patched)
+	}
+
+	return targetObj
+}
+
+// valuePaths returns the paths of the leaves of a value a patch writes at path,
+// which must be mapped too, e.g. those of the elements added to an array
+func valuePaths(path string, value interface{}) []string {
+	var paths []string
+	leafPaths(path, value, &paths)
+	sort.Strings(paths)
+
+	return paths
+}
+
+// pointerTokens splits a JSON Pointer into its unescaped reference tokens
+func pointerTokens(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("error: invalid JSON pointer %s", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+
+	return tokens, nil
+}
+
+// pointerPath returns the hummus path of the value doc has at a JSON Pointer,
+// using doc to tell array indices from keys
+func pointerPath(doc interface{}, tokens []string) string {
+	var path string
+	node := doc
+
+	for _, token := range tokens {
+		if array, ok := node.([]interface{}); ok {
+			index, _ := arrayIndex(token, len(array))
+			path += fmt.Sprintf("[%d]", index)
+
+			node = nil
+			if index >= 0 && index < len(array) {
+				node = array[index]
+			}
+			continue
+		}
+
+		path = joinPath(path, escapeKey(token))
+
+		obj, _ := node.(map[string]interface{})
+		node = obj[token]
+	}
+
+	return path
+}
+
+// changedPath returns the path of an array when adding or removing one of its
+// elements, since that moves the elements after it, and pointerPath otherwise
+func changedPath(doc interface{}, tokens []string) string {
+	if len(tokens) > 0 {
+		if parent, ok := getPointer(doc, tokens[:len(tokens)-1]); ok {
+			if _, isArray := parent.([]interface{}); isArray {
+				return pointerPath(doc, tokens[:len(tokens)-1])
+			}
+		}
+	}
+
+	return pointerPath(doc, tokens)
+}
+
+func getPointer(node interface{}, tokens []string) (interface{}, bool) {
+	for _, token := range tokens {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if node, ok = n[token]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			index, ok := arrayIndex(token, len(n))
+			if !ok || index >= len(n) {
+				return nil, false
+			}
+			node = n[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return node, true
+}
+
+func addPointer(node interface{}, tokens []string, value interface{}) (interface{}, bool) {
+	if len(tokens) == 0 {
+		return value, true
+	}
+
+	var ok bool
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if len(tokens) == 1 {
+			n[tokens[0]] = value
+			return n, true
+		}
+
+		child, found := n[tokens[0]]
+		if !found {
+			return nil, false
+		}
+		n[tokens[0]], ok = addPointer(child, tokens[1:], value)
+		return n, ok
+	case []interface{}:
+		index, valid := arrayIndex(tokens[0], len(n))
+		if !valid || index > len(n) || (len(tokens) > 1 && index == len(n)) {
+			return nil, false
+		}
+
+		if len(tokens) == 1 {
+			n = append(n, nil)
+			copy(n[index+1:], n[index:])
+			n[index] = value
+			return n, true
+		}
+
+		n[index], ok = addPointer(n[index], tokens[1:], value)
+		return n, ok
+	}
+
+	return nil, false
+}
+
+func removePointer(node interface{}, tokens []string) (interface{}, bool) {
+	if len(tokens) == 0 {
+		return nil, false
+	}
+
+	var ok bool
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, found := n[tokens[0]]
+		if !found {
+			return nil, false
+		}
+
+		if len(tokens) == 1 {
+			delete(n, tokens[0])
+			return n, true
+		}
+
+		n[tokens[0]], ok = removePointer(child, tokens[1:])
+		return n, ok
+	case []interface{}:
+		index, valid := arrayIndex(tokens[0], len(n))
+		if !valid || index >= len(n) {
+			return nil, false
+		}
+
+		if len(tokens) == 1 {
+			return append(n[:index], n[index+1:]...), true
+		}
+
+		n[index], ok = removePointer(n[index], tokens[1:])
+		return n, ok
+	}
+
+	return nil, false
+}
+
+// arrayIndex parses an array index of a JSON Pointer, where - is the index
+// past the last element
+func arrayIndex(token string, length int) (int, bool) {
+	if token == "-" {
+		return length, true
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return -1, false
+	}
+
+	return index, true
+}
+
+// mappedPaths returns the paths of the fields of t that Unmarshal stores, with
+// [*] standing for the elements of slices of structs
+func mappedPaths(t reflect.Type, prefix string, tags Tags) []string {
+	var paths []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		_, ht, err := tags.parseField(field)
+		if err != nil || field.PkgPath != "" || ht.isComputed() {
+			continue
+		}
+
+		path := joinPath(prefix, ht.tagName)
+		switch {
+		case ht.kind(field.Type) == nestedField:
+			paths = append(paths, mappedPaths(field.Type, path, tags)...)
+		case ht.kind(field.Type) == structSliceField:
+			paths = append(paths, path+"[*]")
+			paths = append(paths, mappedPaths(field.Type.Elem(), path+"[*]", tags)...)
+		default:
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
+
+// isMapped reports whether a patched path is a mapped path, or is inside or
+// contains one
+func isMapped(path string, mapped []string) bool {
+	segments := pathSegments(path)
+
+	for _, m := range mapped {
+		mappedSegments := pathSegments(m)
+
+		n := len(segments)
+		if len(mappedSegments) < n {
+			n = len(mappedSegments)
+		}
+
+		// slices of structs are mapped whole, but their elements only have
+		// the mapped fields
+		if n == len(mappedSegments) && n < len(segments) && strings.HasSuffix(m, "[*]") {
+			continue
+		}
+
+		if segmentsMatch(mappedSegments[:n], segments[:n]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyFields stores the values doc has at the paths of the fields of v that
+// are patched, clearing those whose paths were removed
+func applyFields(doc interface{}, v reflect.Value, prefix string, patched []string, tags Tags) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		_, ht, err := tags.parseField(field)
+		if err != nil || field.PkgPath != "" || ht.isComputed() {
+			continue
+		}
+
+		path := joinPath(prefix, ht.tagName)
+		if !isPatched(path, patched) {
+			continue
+		}
+
+		if ht.kind(field.Type) == nestedField {
+			if err := applyFields(doc, v.Field(i), path, patched, tags); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !ht.isReversible() {
+			return fmt.Errorf("error: field %s uses format %s, which can't be reversed", field.Name, ht.format)
+		}
+
+		value, found := lookupPath(doc, path)
+		v.Field(i).Set(reflect.Zero(field.Type))
+		if err := unmarshalField(field, ht, value, found, v.Field(i), tags); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isPatched(path string, patched []string) bool {
+	for _, p := range patched {
+		if p == "" || pathsCollide(path, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func joinPath(prefix, path string) string {
+	if prefix == "" || strings.HasPrefix(path, "[") {
+		return prefix + path
+	}
+
+	return prefix + "." + path
+}
+
+// escapeKey escapes the dots of a key for use in a hummus path
+func escapeKey(key string) string {
+	return strings.Replace(key, ".", "#", -1)
+}
@@ -0,0 +1,244 @@
+package hummus_test
+
+import (
+	"fmt"
+
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type PatchInfo struct {
+	Company string   `hummus:"company"`
+	Country string   `hummus:"address.country"`
+	City    string   `hummus:"address.city,omitempty"`
+	Rating  *float64 `hummus:"rating,omitempty"`
+	Brands  []string `hummus:"brands"`
+	Stores  []string `hummus:"a/b~c.stores"`
+}
+
+type ApplyBrand struct {
+	Name  string  `hummus:"name"`
+	Price float64 `hummus:"price"`
+}
+
+type ApplyInfo struct {
+	Company string       `hummus:"company"`
+	Country string       `hummus:"address.country"`
+	City    string       `hummus:"address.city,omitempty"`
+	Brands  []ApplyBrand `hummus:"brands"`
+	Tags    []string     `hummus:"tags"`
+	Domain  string       `hummus:"sites.example#com"`
+	Version string       `hummus:"version,const=v2"`
+}
+
+var _ = Describe("Patches", func() {
+	rating := 4.5
+
+	old := PatchInfo{
+		Company: "hello foods",
+		Country: "US",
+		City:    "Austin",
+		Brands:  []string{"sabra", "tribe", "athenos"},
+		Stores:  []string{"Whole Foods"},
+	}
+
+	new := PatchInfo{
+		Company: "hello foods",
+		Country: "CA",
+		Rating:  &rating,
+		Brands:  []string{"sabra", "cedar's"},
+		Stores:  []string{"Whole Foods", "Safeway"},
+	}
+
+	Describe("MergePatch", func() {
+		It("writes the changed paths, with null for removed ones", func() {
+			patch, err := hummus.MergePatch(old, new)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patch).To(MatchJSON(`
+			{
+				"address": {"country": "CA", "city": null},
+				"rating": 4.5,
+				"brands": ["sabra", "cedar's"],
+				"a/b~c": {"stores": ["Whole Foods", "Safeway"]}
+			}`))
+		})
+
+		It("writes an empty patch for equal documents", func() {
+			patch, err := hummus.MergePatch(old, old)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patch).To(MatchJSON(`{}`))
+		})
+	})
+
+	Describe("JSONPatch", func() {
+		It("writes operations for the changed paths", func() {
+			patch, err := hummus.JSONPatch(old, new)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patch).To(MatchJSON(`
+			[
+				{"op": "add", "path": "/a~1b~0c/stores/1", "value": "Safeway"},
+				{"op": "remove", "path": "/address/city"},
+				{"op": "replace", "path": "/address/country", "value": "CA"},
+				{"op": "replace", "path": "/brands/1", "value": "cedar's"},
+				{"op": "remove", "path": "/brands/2"},
+				{"op": "add", "path": "/rating", "value": 4.5}
+			]`))
+		})
+
+		It("removes array elements from the end", func() {
+			patch, err := hummus.JSONPatch(PatchInfo{Brands: []string{"a", "b", "c"}}, PatchInfo{Brands: []string{"a"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patch).To(MatchJSON(`
+			[
+				{"op": "remove", "path": "/brands/2"},
+				{"op": "remove", "path": "/brands/1"}
+			]`))
+		})
+
+		It("writes no operations for equal documents", func() {
+			patch, err := hummus.JSONPatch(old, old)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patch).To(MatchJSON(`[]`))
+		})
+	})
+
+	Context("when a struct can't be marshaled", func() {
+		It("returns an error", func() {
+			bad := struct {
+				Name string `hummus:"name,nope"`
+			}{}
+
+			_, err := hummus.MergePatch(bad, old)
+			Expect(err).To(MatchError("error: unknown struct tag option nope"))
+
+			_, err = hummus.JSONPatch(old, bad)
+			Expect(err).To(MatchError("error: unknown struct tag option nope"))
+		})
+	})
+
+	Describe("ApplyPatch", func() {
+		var info ApplyInfo
+
+		BeforeEach(func() {
+			info = ApplyInfo{
+				Company: "hello foods",
+				Country: "US",
+				City:    "Austin",
+				Brands:  []ApplyBrand{{Name: "sabra", Price: 3.5}},
+				Tags:    []string{"vegan"},
+				Domain:  "hello.example.com",
+			}
+		})
+
+		It("applies JSON Patch operations to the mapped fields", func() {
+			err := hummus.ApplyPatch(&info, []byte(`[
+				{"op": "test", "path": "/company", "value": "hello foods"},
+				{"op": "replace", "path": "/address/country", "value": "CA"},
+				{"op": "remove", "path": "/address/city"},
+				{"op": "replace", "path": "/brands/0/price", "value": 4.5},
+				{"op": "add", "path": "/brands/-", "value": {"name": "tribe", "price": 3}},
+				{"op": "add", "path": "/tags/0", "value": "organic"},
+				{"op": "copy", "from": "/brands/0/name", "path": "/company"},
+				{"op": "replace", "path": "/sites/example.com", "value": "sabra.example.com"}
+			]`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info).To(Equal(ApplyInfo{
+				Company: "sabra",
+				Country: "CA",
+				Brands:  []ApplyBrand{{Name: "sabra", Price: 4.5}, {Name: "tribe", Price: 3}},
+				Tags:    []string{"organic", "vegan"},
+				Domain:  "sabra.example.com",
+			}))
+		})
+
+		It("applies JSON Merge Patches to the mapped fields", func() {
+			err := hummus.ApplyPatch(&info, []byte(`{
+				"address": {"city": null, "country": "CA"},
+				"brands": [{"name": "tribe", "price": 3}]
+			}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info).To(Equal(ApplyInfo{
+				Company: "hello foods",
+				Country: "CA",
+				Brands:  []ApplyBrand{{Name: "tribe", Price: 3}},
+				Tags:    []string{"vegan"},
+				Domain:  "hello.example.com",
+			}))
+		})
+
+		Context("special/failure cases", func() {
+			Context("when a patched path has no field mapped to it", func() {
+				It("returns an error and leaves the struct alone", func() {
+					original := info
+
+					err := hummus.ApplyPatch(&info, []byte(`[
+						{"op": "replace", "path": "/company", "value": "tribe"},
+						{"op": "add", "path": "/brands/0/rating", "value": 5}
+					]`))
+					Expect(err).To(MatchError("error: no field is mapped to path brands[0].rating"))
+
+					err = hummus.ApplyPatch(&info, []byte(`{"version": "v3"}`))
+					Expect(err).To(MatchError("error: no field is mapped to path version"))
+					Expect(info).To(Equal(original))
+				})
+
+				It("returns an error for array elements past the mapped ones", func() {
+					type Order struct {
+						First  string `hummus:"items[0].sku"`
+						Second string `hummus:"items[1].sku"`
+					}
+
+					order := Order{First: "a", Second: "b"}
+					err := hummus.ApplyPatch(&order, []byte(`[{"op": "add", "path": "/items/-", "value": {"sku": "c"}}]`))
+					Expect(err).To(MatchError("error: no field is mapped to path items[2].sku"))
+
+					err = hummus.ApplyPatch(&order, []byte(`{"items": [{"sku": "x"}, {"sku": "y"}, {"sku": "z"}]}`))
+					Expect(err).To(MatchError("error: no field is mapped to path items[2].sku"))
+					Expect(order).To(Equal(Order{First: "a", Second: "b"}))
+
+					err = hummus.ApplyPatch(&order, []byte(`{"items": [{"sku": "x"}, {"sku": "y"}]}`))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(order).To(Equal(Order{First: "x", Second: "y"}))
+				})
+			})
+
+			Context("when a patched field has a custom format", func() {
+				It("returns an error and leaves the struct alone", func() {
+					hummus.RegisterFormatter("cents", func(value interface{}) (interface{}, error) {
+						return fmt.Sprintf("%.2f", float64(value.(int))/100), nil
+					})
+
+					type Order struct {
+						Price int `hummus:"price,format=cents"`
+					}
+
+					order := Order{Price: 1050}
+					err := hummus.ApplyPatch(&order, []byte(`{"price": "99.99"}`))
+					Expect(err).To(MatchError("error: field Price uses format cents, which can't be reversed"))
+					Expect(order.Price).To(Equal(1050))
+				})
+			})
+
+			Context("when an operation fails", func() {
+				It("returns an error", func() {
+					err := hummus.ApplyPatch(&info, []byte(`[{"op": "test", "path": "/company", "value": "tribe"}]`))
+					Expect(err).To(MatchError("error: test of /company failed"))
+
+					err = hummus.ApplyPatch(&info, []byte(`[{"op": "remove", "path": "/brands/3"}]`))
+					Expect(err).To(MatchError("error: cannot remove /brands/3"))
+
+					err = hummus.ApplyPatch(&info, []byte(`[{"op": "add", "path": "company"}]`))
+					Expect(err).To(MatchError("error: invalid JSON pointer company"))
+				})
+			})
+
+			Context("when not given a pointer to a struct", func() {
+				It("returns an error", func() {
+					err := hummus.ApplyPatch(info, []byte(`{}`))
+					Expect(err).To(MatchError("error: can only apply a patch to a pointer to a struct"))
+				})
+			})
+		})
+	})
+})
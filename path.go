@@ -0,0 +1,172 @@
+package hummus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Get returns the value found at a hummus path (e.g. "brands[0].stores[1].price")
+// in a JSON document.
+func Get(data []byte, path string) (interface{}, error) {
+	if path == "" {
+		return nil, errors.New("error: empty path")
+	}
+
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := Lookup(doc, path)
+	if !ok {
+		return nil, fmt.Errorf("error: path %s not found", path)
+	}
+
+	return value, nil
+}
+
+// Lookup is like Get, but for a document that is already decoded into maps,
+// slices and values. It reports whether the path was found.
+func Lookup(doc interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return nil, false
+	}
+
+	return lookupPath(doc, path)
+}
+
+// Set returns a copy of a JSON document with the value at a hummus path
+// replaced. Missing objects are created and arrays are padded with nulls, just
+// like Marshal does.
+func Set(data []byte, path string, value interface{}) ([]byte, error) {
+	if path == "" {
+		return []byte{}, errors.New("error: empty path")
+	}
+
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	doc, ok := setPath(doc, path, value)
+	if !ok {
+		return []byte{}, fmt.Errorf("error: path %s collides with an existing value", path)
+	}
+
+	return json.Marshal(doc)
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func lookupPath(node interface{}, path string) (interface{}, bool) {
+	at, isArray := parseArrayTag(path)
+	if !isArray {
+		return lookupKeys(node, path)
+	}
+
+	container, ok := lookupKeys(node, at.arrayPath)
+	if !ok {
+		return nil, false
+	}
+
+	array, ok := container.([]interface{})
+	if !ok || at.arrayIndex >= len(array) {
+		return nil, false
+	}
+
+	if at.childPath == "" {
+		return array[at.arrayIndex], true
+	}
+
+	return lookupPath(array[at.arrayIndex], at.childPath)
+}
+
+func lookupKeys(node interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return node, true
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		node, ok = obj[unescapeKey(key)]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return node, true
+}
+
+func setPath(node interface{}, path string, value interface{}) (interface{}, bool) {
+	at, isArray := parseArrayTag(path)
+	if !isArray {
+		return setKeys(node, path, value)
+	}
+
+	container, _ := lookupKeys(node, at.arrayPath)
+	array, ok := container.([]interface{})
+	if container != nil && !ok {
+		return nil, false
+	}
+
+	for len(array) <= at.arrayIndex {
+		array = append(array, nil)
+	}
+
+	if at.childPath == "" {
+		array[at.arrayIndex] = value
+	} else {
+		array[at.arrayIndex], ok = setPath(array[at.arrayIndex], at.childPath, value)
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return setKeys(node, at.arrayPath, array)
+}
+
+func setKeys(node interface{}, path string, value interface{}) (interface{}, bool) {
+	if path == "" {
+		return value, true
+	}
+
+	if node == nil {
+		node = map[string]interface{}{}
+	}
+
+	obj, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	keys := strings.SplitN(path, ".", 2)
+	key := unescapeKey(keys[0])
+	if len(keys) == 1 {
+		obj[key] = value
+		return obj, true
+	}
+
+	obj[key], ok = setKeys(obj[key], keys[1], value)
+	return obj, ok
+}
+
+func unescapeKey(key string) string {
+	return strings.Replace(key, "#", ".", -1)
+}
@@ -0,0 +1,150 @@
+package hummus_test
+
+import (
+	"encoding/json"
+
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Paths", func() {
+	doc := []byte(`{
+		"company": "hello foods",
+		"brands": [
+			{
+				"name": "sabra",
+				"stores": [
+					{"name": "safeway", "price": 5},
+					{"name": "wholefoods", "price": 10}
+				]
+			}
+		],
+		"outer": {
+			"inner.notchild": [{"name": "C_val"}]
+		}
+	}`)
+
+	Describe("Get", func() {
+		It("gets simple and nested values", func() {
+			value, err := hummus.Get(doc, "company")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal("hello foods"))
+
+			value, err = hummus.Get(doc, "brands[0].stores[1].price")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal(json.Number("10")))
+
+			value, err = hummus.Get(doc, "brands[0].stores[0]")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal(map[string]interface{}{
+				"name":  "safeway",
+				"price": json.Number("5"),
+			}))
+		})
+
+		It("allows one to escape dots", func() {
+			value, err := hummus.Get(doc, "outer.inner#notchild[0].name")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal("C_val"))
+		})
+
+		Context("when the path does not exist", func() {
+			It("returns an error", func() {
+				_, err := hummus.Get(doc, "brands[1].name")
+				Expect(err).To(MatchError("error: path brands[1].name not found"))
+
+				_, err = hummus.Get(doc, "company.name")
+				Expect(err).To(MatchError("error: path company.name not found"))
+			})
+		})
+
+		Context("when the path is empty", func() {
+			It("returns an error", func() {
+				_, err := hummus.Get(doc, "")
+				Expect(err).To(MatchError("error: empty path"))
+			})
+		})
+	})
+
+	Describe("Lookup", func() {
+		It("looks up values in a decoded document", func() {
+			decoded := map[string]interface{}{
+				"brands": []interface{}{map[string]interface{}{"name": "sabra"}},
+				"a.b":    "dotted",
+			}
+
+			value, found := hummus.Lookup(decoded, "brands[0].name")
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("sabra"))
+
+			value, found = hummus.Lookup(decoded, "a#b")
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("dotted"))
+
+			_, found = hummus.Lookup(decoded, "brands[1].name")
+			Expect(found).To(BeFalse())
+
+			_, found = hummus.Lookup(decoded, "")
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("Set", func() {
+		It("replaces existing values", func() {
+			outJSON, err := hummus.Set(doc, "brands[0].stores[1].price", 12)
+			Expect(err).NotTo(HaveOccurred())
+
+			value, err := hummus.Get(outJSON, "brands[0].stores[1].price")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal(json.Number("12")))
+
+			value, err = hummus.Get(outJSON, "brands[0].stores[1].name")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal("wholefoods"))
+		})
+
+		It("creates missing objects and pads arrays with nulls", func() {
+			outJSON, err := hummus.Set([]byte(`{"company": "hello foods"}`), "brands[1].flavors[0]", "jalapeno")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outJSON).To(MatchJSON(`{
+				"company": "hello foods",
+				"brands": [
+					null,
+					{
+						"flavors": ["jalapeno"]
+					}
+				]
+			}`))
+		})
+
+		It("allows one to escape dots", func() {
+			outJSON, err := hummus.Set([]byte(`{}`), "outer.inner#notchild.name", "A_val")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outJSON).To(MatchJSON(`{
+				"outer": {
+					"inner.notchild": {
+						"name": "A_val"
+					}
+				}
+			}`))
+		})
+
+		Context("when the path runs through a non-object value", func() {
+			It("returns an error", func() {
+				_, err := hummus.Set(doc, "company.name", "sabra")
+				Expect(err).To(MatchError("error: path company.name collides with an existing value"))
+
+				_, err = hummus.Set(doc, "company[0]", "sabra")
+				Expect(err).To(MatchError("error: path company[0] collides with an existing value"))
+			})
+		})
+
+		Context("when passed invalid JSON", func() {
+			It("returns an error", func() {
+				_, err := hummus.Set([]byte(`{"company": `), "company", "sabra")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,298 @@
+package hummus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/aditya87/hummus/tree"
+)
+
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+type fieldSchema struct {
+	schema   map[string]interface{}
+	typ      reflect.Type
+	kind     fieldKind
+	optional bool
+}
+
+// Schema generates a JSON Schema (draft 2020-12) for the documents that Marshal
+// produces from values of type t. Fields tagged omitempty aren't required.
+func Schema(t reflect.Type) ([]byte, error) {
+	return Tags{}.Schema(t)
+}
+
+// Schema is like the package-level Schema, but lays out the chosen tags
+func (tags Tags) Schema(t reflect.Type) ([]byte, error) {
+	if t.Kind() != reflect.Struct {
+		return []byte{}, errors.New("error: can only generate schemas for structs")
+	}
+
+	schema, err := structSchema(t, map[reflect.Type]bool{}, tags)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	schema["$schema"] = schemaDialect
+	return json.Marshal(schema)
+}
+
+func structSchema(t reflect.Type, seen map[reflect.Type]bool, tags Tags) (map[string]interface{}, error) {
+	layout, err := layoutFields(t, seen, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, _ := documentSchema(layout)
+	return schema, nil
+}
+
+// layoutFields lays out the schemas of t's fields in a tree, the same way
+// Marshal lays out their values, and returns the resulting document
+func layoutFields(t reflect.Type, seen map[reflect.Type]bool, tags Tags) (interface{}, error) {
+	seen[t] = true
+	defer delete(seen, t)
+
+	schemaTree := tree.NewTree()
+	if err := insertFieldSchemas(schemaTree, t, "", false, seen, tags); err != nil {
+		return nil, err
+	}
+
+	return schemaTree.BuildJSON().Data(), nil
+}
+
+func insertFieldSchemas(schemaTree tree.Tree, t reflect.Type, prefix string, optional bool, seen map[reflect.Type]bool, tags Tags) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, ht, err := tags.parseField(field)
+		if err != nil && tag.Get("hummus") == "" {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		path := ht.tagName
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		kind := ht.kind(field.Type)
+		if kind == nestedField {
+			err = insertFieldSchemas(schemaTree, field.Type, path, optional, seen, tags)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		typ := formattedType(ht, field.Type)
+		if ht.isComputed() {
+			typ = computedType(ht, field)
+		} else if ht.asString && quotable(typ) && typ.Kind() == reflect.Ptr {
+			typ = reflect.PtrTo(reflect.TypeOf(""))
+		} else if ht.asString && quotable(typ) {
+			typ = reflect.TypeOf("")
+		}
+
+		var schema map[string]interface{}
+		if kind == structSliceField {
+			schema, err = structSliceSchema(typ, seen, tags)
+		} else {
+			schema, err = typeSchema(typ, seen)
+		}
+		if err != nil {
+			return err
+		}
+
+		if ht.hasConst {
+			value, err := computedValue(ht, field, reflect.Value{})
+			if err != nil {
+				return err
+			}
+			schema["const"] = value
+		}
+
+		err = schemaTree.Insert(fmt.Sprintf("hummus:%q", path), &fieldSchema{
+			schema:   schema,
+			typ:      typ,
+			kind:     kind,
+			optional: optional || ht.omitEmpty,
+		}, false)
+		if err != nil {
+			return fmt.Errorf("error: field %s: %s", field.Name, errorText(err))
+		}
+	}
+
+	return nil
+}
+
+// documentSchema converts a document built out of field schemas into a schema,
+// and reports whether the document is always present
+func documentSchema(doc interface{}) (map[string]interface{}, bool) {
+	switch value := doc.(type) {
+	case *fieldSchema:
+		return value.schema, !value.optional
+	case map[string]interface{}:
+		properties := make(map[string]interface{})
+		required := []string{}
+
+		for key, member := range value {
+			memberSchema, memberRequired := documentSchema(member)
+			properties[key] = memberSchema
+			if memberRequired {
+				required = append(required, key)
+			}
+		}
+		sort.Strings(required)
+
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+
+		return schema, len(required) > 0
+	case []interface{}:
+		prefixItems := make([]interface{}, len(value))
+		minItems := 0
+
+		for i, element := range value {
+			if element == nil {
+				prefixItems[i] = map[string]interface{}{"type": "null"}
+				continue
+			}
+
+			elementSchema, elementRequired := documentSchema(element)
+			prefixItems[i] = elementSchema
+			if elementRequired {
+				minItems = i + 1
+			}
+		}
+
+		// optional elements before the last required one are padded with null
+		// when they are left out
+		for i := 0; i < minItems; i++ {
+			if _, required := documentSchema(value[i]); value[i] != nil && !required {
+				prefixItems[i] = map[string]interface{}{
+					"anyOf": []interface{}{prefixItems[i], map[string]interface{}{"type": "null"}},
+				}
+			}
+		}
+
+		schema := map[string]interface{}{
+			"type":        "array",
+			"prefixItems": prefixItems,
+			"items":       false,
+		}
+		if minItems > 0 {
+			schema["minItems"] = minItems
+		}
+
+		return schema, minItems > 0
+	}
+
+	return map[string]interface{}{}, false
+}
+
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) (map[string]interface{}, error) {
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer", "minimum": 0}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Slice:
+		// nil slices are written as null
+		if t.Elem().Kind() == reflect.Uint8 {
+			return nullable(map[string]interface{}{"type": "string", "contentEncoding": "base64"}), nil
+		}
+
+		items, err := jsonTypeSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+
+		return nullable(map[string]interface{}{"type": "array", "items": items}), nil
+	case reflect.Array:
+		items, err := jsonTypeSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]interface{}{
+			"type":     "array",
+			"items":    items,
+			"minItems": t.Len(),
+			"maxItems": t.Len(),
+		}, nil
+	case reflect.Map:
+		values, err := jsonTypeSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+
+		// nil maps are written as null
+		return nullable(map[string]interface{}{"type": "object", "additionalProperties": values}), nil
+	case reflect.Ptr:
+		elem, err := jsonTypeSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+
+		return nullable(elem), nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	}
+
+	return nil, fmt.Errorf("error: cannot generate a schema for kind %s", t.Kind())
+}
+
+// structSliceSchema describes a slice of structs marshaled by hummus
+func structSliceSchema(t reflect.Type, seen map[reflect.Type]bool, tags Tags) (map[string]interface{}, error) {
+	items := map[string]interface{}{"type": "object"}
+	if !seen[t.Elem()] {
+		var err error
+		items, err = structSchema(t.Elem(), seen, tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nullable(map[string]interface{}{"type": "array", "items": items}), nil
+}
+
+// jsonTypeSchema is typeSchema for values that are handed straight to
+// encoding/json, which doesn't know about hummus tags
+func jsonTypeSchema(t reflect.Type, seen map[reflect.Type]bool) (map[string]interface{}, error) {
+	if t.Kind() == reflect.Struct {
+		return map[string]interface{}{"type": "object"}, nil
+	}
+
+	return typeSchema(t, seen)
+}
+
+// nullable returns a schema accepting null as well as the values of schema
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		for _, s := range anyOf {
+			if reflect.DeepEqual(s, map[string]interface{}{"type": "null"}) {
+				return schema
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}},
+	}
+}
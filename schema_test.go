@@ -0,0 +1,237 @@
+package hummus_test
+
+import (
+	"reflect"
+
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schema", func() {
+	It("generates a schema for the nested document", func() {
+		type Info struct {
+			Company           string   `hummus:"company"`
+			Public            bool     `hummus:"public,omitempty"`
+			Brand0Name        string   `hummus:"brands[0].name"`
+			Brand0Store1Price float64  `hummus:"brands[0].stores[1].price,omitempty"`
+			Brand1Name        string   `hummus:"brands[1].name,omitempty"`
+			Tags              []string `hummus:"reputation.tags"`
+			Escaped           int      `hummus:"properties#count"`
+			Skipped           string
+		}
+
+		schema, err := hummus.Schema(reflect.TypeOf(Info{}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schema).To(MatchJSON(`{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"company": {"type": "string"},
+				"public": {"type": "boolean"},
+				"brands": {
+					"type": "array",
+					"prefixItems": [
+						{
+							"type": "object",
+							"properties": {
+								"name": {"type": "string"},
+								"stores": {
+									"type": "array",
+									"prefixItems": [
+										{"type": "null"},
+										{
+											"type": "object",
+											"properties": {
+												"price": {"type": "number"}
+											}
+										}
+									],
+									"items": false
+								}
+							},
+							"required": ["name"]
+						},
+						{
+							"type": "object",
+							"properties": {
+								"name": {"type": "string"}
+							}
+						}
+					],
+					"items": false,
+					"minItems": 1
+				},
+				"reputation": {
+					"type": "object",
+					"properties": {
+						"tags": {"anyOf": [{"type": "array", "items": {"type": "string"}}, {"type": "null"}]}
+					},
+					"required": ["tags"]
+				},
+				"properties.count": {"type": "integer"}
+			},
+			"required": ["brands", "company", "properties.count", "reputation"]
+		}`))
+	})
+
+	It("merges nested structs and describes slices of structs", func() {
+		type Store struct {
+			Name string `hummus:"name"`
+		}
+
+		type Brand struct {
+			Name   string `hummus:"name"`
+			Flavor string `hummus:"flavor,omitempty"`
+		}
+
+		type Info struct {
+			B      Brand   `hummus:"brand"`
+			Extra  string  `hummus:"brand.extra"`
+			Stores []Store `hummus:"stores"`
+		}
+
+		schema, err := hummus.Schema(reflect.TypeOf(Info{}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schema).To(MatchJSON(`{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"brand": {
+					"type": "object",
+					"properties": {
+						"name": {"type": "string"},
+						"flavor": {"type": "string"},
+						"extra": {"type": "string"}
+					},
+					"required": ["extra", "name"]
+				},
+				"stores": {
+					"anyOf": [
+						{
+							"type": "array",
+							"items": {
+								"type": "object",
+								"properties": {
+									"name": {"type": "string"}
+								},
+								"required": ["name"]
+							}
+						},
+						{"type": "null"}
+					]
+				}
+			},
+			"required": ["brand", "stores"]
+		}`))
+	})
+
+	It("allows nil slices, maps and byte slices to be null, like Marshal writes them", func() {
+		type Info struct {
+			Tags  []string          `hummus:"tags"`
+			Attrs map[string]string `hummus:"attrs"`
+			Data  []byte            `hummus:"data"`
+			Ref   *[]string         `hummus:"ref"`
+		}
+
+		outJSON, err := hummus.Marshal(Info{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outJSON).To(MatchJSON(`{"tags": null, "attrs": null, "data": null, "ref": null}`))
+
+		schema, err := hummus.Schema(reflect.TypeOf(Info{}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schema).To(MatchJSON(`{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"tags": {"anyOf": [{"type": "array", "items": {"type": "string"}}, {"type": "null"}]},
+				"attrs": {"anyOf": [{"type": "object", "additionalProperties": {"type": "string"}}, {"type": "null"}]},
+				"data": {"anyOf": [{"type": "string", "contentEncoding": "base64"}, {"type": "null"}]},
+				"ref": {"anyOf": [{"type": "array", "items": {"type": "string"}}, {"type": "null"}]}
+			},
+			"required": ["attrs", "data", "ref", "tags"]
+		}`))
+	})
+
+	It("allows optional elements before required ones to be null", func() {
+		type Info struct {
+			Brand0 string `hummus:"brands[0],omitempty"`
+			Brand1 string `hummus:"brands[1]"`
+		}
+
+		schema, err := hummus.Schema(reflect.TypeOf(Info{}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schema).To(MatchJSON(`{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"brands": {
+					"type": "array",
+					"prefixItems": [
+						{"anyOf": [{"type": "string"}, {"type": "null"}]},
+						{"type": "string"}
+					],
+					"items": false,
+					"minItems": 2
+				}
+			},
+			"required": ["brands"]
+		}`))
+	})
+
+	It("describes fields with the string option as strings", func() {
+		type Info struct {
+			ID  int64  `hummus:"order.id,string"`
+			Ref *int64 `hummus:"order.ref,string"`
+		}
+
+		schema, err := hummus.Schema(reflect.TypeOf(Info{}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schema).To(MatchJSON(`{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"order": {
+					"type": "object",
+					"properties": {
+						"id": {"type": "string"},
+						"ref": {"anyOf": [{"type": "string"}, {"type": "null"}]}
+					},
+					"required": ["id", "ref"]
+				}
+			},
+			"required": ["order"]
+		}`))
+	})
+
+	Context("special/failure cases", func() {
+		Context("when passed a type that isn't a struct", func() {
+			It("returns an error", func() {
+				_, err := hummus.Schema(reflect.TypeOf("sabra"))
+				Expect(err).To(MatchError("error: can only generate schemas for structs"))
+			})
+		})
+
+		Context("when a field has an unsupported kind", func() {
+			It("returns an error", func() {
+				type Info struct {
+					C chan int `hummus:"channel"`
+				}
+
+				_, err := hummus.Schema(reflect.TypeOf(Info{}))
+				Expect(err).To(MatchError("error: cannot generate a schema for kind chan"))
+			})
+		})
+
+		Context("when passed an unknown struct tag option", func() {
+			It("returns an error", func() {
+				type Info struct {
+					Brand string `hummus:"brand,omitempty,blah"`
+				}
+
+				_, err := hummus.Schema(reflect.TypeOf(Info{}))
+				Expect(err).To(MatchError("error: unknown struct tag option blah"))
+			})
+		})
+	})
+})
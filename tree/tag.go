@@ -0,0 +1,156 @@
+package tree
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Tag is a parsed hummus struct tag: a path followed by comma-separated options,
+// which are either flags such as omitempty or key=value pairs such as
+// default=USD. The values of text options such as expr can contain commas, so
+// expr={Last}, {First} is read whole. Paths of tags with the pointer option are
+// JSON Pointers (RFC 6901), e.g. /brands/0/name,pointer, which are normalized
+// into the dotted form.
+type Tag struct {
+	Path    string
+	Options map[string]string
+}
+
+var pointerIndexRegex = regexp.MustCompile(`^(0|[1-9][0-9]*)$`)
+
+var optionNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+var (
+	optionsMu sync.RWMutex
+	options   = map[string]bool{
+		"omitempty": false,
+		"pointer":   false,
+	}
+	textOptions = map[string]bool{}
+)
+
+// RegisterOption makes ParseTag accept an option. Options that take a value are
+// written as name=value, others as a bare name. It panics if the option is
+// already registered.
+func RegisterOption(name string, takesValue bool) {
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+
+	if _, exists := options[name]; exists {
+		panic(fmt.Sprintf("hummus: option %s registered twice", name))
+	}
+	options[name] = takesValue
+}
+
+// RegisterTextOption makes ParseTag accept an option whose value is free text,
+// which runs on past commas up to the next part that looks like an option. It
+// panics if the option is already registered.
+func RegisterTextOption(name string) {
+	RegisterOption(name, true)
+
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+	textOptions[name] = true
+}
+
+// ParseTag parses the hummus tag of a struct field, and returns an error for
+// options that aren't registered or are missing (or have unexpected) values.
+func ParseTag(tag reflect.StructTag) (Tag, error) {
+	hummusTagString := tag.Get("hummus")
+	if hummusTagString == "" {
+		return Tag{}, fmt.Errorf("error: invalid struct tag %s", tag)
+	}
+
+	tagFields := strings.Split(hummusTagString, ",")
+	t := Tag{
+		Path:    tagFields[0],
+		Options: make(map[string]string),
+	}
+
+	optionsMu.RLock()
+	defer optionsMu.RUnlock()
+
+	for i := 1; i < len(tagFields); i++ {
+		name, value := tagFields[i], ""
+		hasValue := strings.Contains(name, "=")
+		if hasValue {
+			parts := strings.SplitN(name, "=", 2)
+			name, value = parts[0], parts[1]
+		}
+
+		for hasValue && textOptions[name] && i+1 < len(tagFields) && !isOption(tagFields[i+1]) {
+			i++
+			value += "," + tagFields[i]
+		}
+
+		takesValue, known := options[name]
+		switch {
+		case !known:
+			return Tag{}, fmt.Errorf("error: unknown struct tag option %s", name)
+		case takesValue && !hasValue:
+			return Tag{}, fmt.Errorf("error: struct tag option %s needs a value", name)
+		case !takesValue && hasValue:
+			return Tag{}, fmt.Errorf("error: struct tag option %s takes no value", name)
+		}
+
+		if _, duplicate := t.Options[name]; duplicate {
+			return Tag{}, fmt.Errorf("error: duplicate struct tag option %s", name)
+		}
+		t.Options[name] = value
+	}
+
+	if t.Has("pointer") {
+		path, err := pointerPath(t.Path)
+		if err != nil {
+			return Tag{}, err
+		}
+		t.Path = path
+	}
+
+	return t, nil
+}
+
+// isOption reports whether a comma-separated part of a tag looks like an option,
+// registered or not, rather than continuing the value of a text option
+func isOption(part string) bool {
+	return optionNameRegex.MatchString(strings.SplitN(part, "=", 2)[0])
+}
+
+// Has reports whether the tag has the named option
+func (t Tag) Has(name string) bool {
+	_, ok := t.Options[name]
+	return ok
+}
+
+// pointerPath converts a JSON Pointer into a dotted path: numeric tokens become
+// array indices, and dots in keys are escaped as #
+func pointerPath(pointer string) (string, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return "", fmt.Errorf("error: invalid JSON pointer %s", pointer)
+	}
+
+	var path string
+
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+
+		if token == "" || strings.ContainsAny(token, "#[]") {
+			return "", fmt.Errorf("error: invalid JSON pointer token %q in %s", token, pointer)
+		}
+
+		if pointerIndexRegex.MatchString(token) {
+			path += "[" + token + "]"
+			continue
+		}
+
+		if path != "" {
+			path += "."
+		}
+		path += strings.Replace(token, ".", "#", -1)
+	}
+
+	return path, nil
+}
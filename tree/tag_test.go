@@ -0,0 +1,116 @@
+package tree_test
+
+import (
+	"reflect"
+
+	"github.com/aditya87/hummus/tree"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseTag", func() {
+	tree.RegisterOption("test-flag", false)
+	tree.RegisterOption("test-key", true)
+	tree.RegisterTextOption("test-text")
+
+	It("parses the path and options", func() {
+		tag, err := tree.ParseTag(reflect.StructTag(`hummus:"brands[0].name,omitempty,test-flag,test-key=a=b"`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tag.Path).To(Equal("brands[0].name"))
+		Expect(tag.Options).To(Equal(map[string]string{
+			"omitempty": "",
+			"test-flag": "",
+			"test-key":  "a=b",
+		}))
+		Expect(tag.Has("omitempty")).To(BeTrue())
+		Expect(tag.Has("required")).To(BeFalse())
+	})
+
+	It("accepts empty values", func() {
+		tag, err := tree.ParseTag(reflect.StructTag(`hummus:"name,test-key="`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tag.Options).To(HaveKeyWithValue("test-key", ""))
+	})
+
+	It("reads commas in text option values that aren't followed by an option", func() {
+		tag, err := tree.ParseTag(reflect.StructTag(`hummus:"name,test-text={Last}, {First},omitempty"`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tag.Options).To(Equal(map[string]string{
+			"test-text": "{Last}, {First}",
+			"omitempty": "",
+		}))
+
+		tag, err = tree.ParseTag(reflect.StructTag(`hummus:"name,omitempty,test-text=[1,2]"`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tag.Options).To(HaveKeyWithValue("test-text", "[1,2]"))
+	})
+
+	It("normalizes JSON Pointer paths", func() {
+		tag, err := tree.ParseTag(reflect.StructTag(`hummus:"/brands/0/stores/12/price,omitempty,pointer"`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tag.Path).To(Equal("brands[0].stores[12].price"))
+		Expect(tag.Has("omitempty")).To(BeTrue())
+
+		tag, err = tree.ParseTag(reflect.StructTag(`hummus:"/sites/example.com/a~1b/~01/007,pointer"`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tag.Path).To(Equal("sites.example#com.a/b.~1.007"))
+	})
+
+	It("takes paths starting with a slash as keys without the pointer option", func() {
+		tag, err := tree.ParseTag(reflect.StructTag(`hummus:"/api.v,omitempty"`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tag.Path).To(Equal("/api.v"))
+	})
+
+	Context("special/failure cases", func() {
+		It("rejects tags without a hummus key", func() {
+			_, err := tree.ParseTag(reflect.StructTag(`json:"name"`))
+			Expect(err).To(MatchError(`error: invalid struct tag json:"name"`))
+		})
+
+		It("rejects unknown options", func() {
+			_, err := tree.ParseTag(reflect.StructTag(`hummus:"name,foo"`))
+			Expect(err).To(MatchError("error: unknown struct tag option foo"))
+		})
+
+		It("rejects options with missing or unexpected values", func() {
+			_, err := tree.ParseTag(reflect.StructTag(`hummus:"name,test-key"`))
+			Expect(err).To(MatchError("error: struct tag option test-key needs a value"))
+
+			_, err = tree.ParseTag(reflect.StructTag(`hummus:"name,omitempty=true"`))
+			Expect(err).To(MatchError("error: struct tag option omitempty takes no value"))
+		})
+
+		It("rejects invalid JSON Pointer paths", func() {
+			_, err := tree.ParseTag(reflect.StructTag(`hummus:"/brands//name,pointer"`))
+			Expect(err).To(MatchError(`error: invalid JSON pointer token "" in /brands//name`))
+
+			_, err = tree.ParseTag(reflect.StructTag(`hummus:"/brands[0],pointer"`))
+			Expect(err).To(MatchError(`error: invalid JSON pointer token "brands[0]" in /brands[0]`))
+
+			_, err = tree.ParseTag(reflect.StructTag(`hummus:"brands.name,pointer"`))
+			Expect(err).To(MatchError("error: invalid JSON pointer brands.name"))
+		})
+
+		It("rejects unknown options after option values", func() {
+			_, err := tree.ParseTag(reflect.StructTag(`hummus:"name,test-text=USD,requird"`))
+			Expect(err).To(MatchError("error: unknown struct tag option requird"))
+
+			_, err = tree.ParseTag(reflect.StructTag(`hummus:"name,test-key=admin,omitemtpy"`))
+			Expect(err).To(MatchError("error: unknown struct tag option omitemtpy"))
+
+			_, err = tree.ParseTag(reflect.StructTag(`hummus:"name,test-key=[1,2]"`))
+			Expect(err).To(MatchError("error: unknown struct tag option 2]"))
+		})
+
+		It("rejects duplicate options", func() {
+			_, err := tree.ParseTag(reflect.StructTag(`hummus:"name,omitempty,omitempty"`))
+			Expect(err).To(MatchError("error: duplicate struct tag option omitempty"))
+		})
+
+		It("panics when an option is registered twice", func() {
+			Expect(func() { tree.RegisterOption("omitempty", false) }).To(Panic())
+			Expect(func() { tree.RegisterTextOption("test-key") }).To(Panic())
+		})
+	})
+})
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -18,93 +19,408 @@ type Node struct {
 	ArrayChildren []interface{}
 }
 
+// Tree holds the nodes of a document by path. NodeMap should only be changed
+// with Insert and Delete, which keep the tree's index of paths up to date.
 type Tree struct {
 	NodeMap map[string]Node
+
+	// index lists the node paths under each path, so that inserts don't have
+	// to scan every node. Trees without one (array elements, until they grow
+	// past indexThreshold nodes) are scanned.
+	index *pathIndex
 }
 
-type hummusTag struct {
-	tagName   string
-	omitEmpty bool
+// pathIndex maps every path that has nodes inside it to their paths
+type pathIndex struct {
+	count int
+	under map[string]map[string]bool
 }
 
+// indexThreshold is the number of nodes an array element grows to before it
+// gets an index
+const indexThreshold = 64
+
+// arrayRegex needs to be non-greedy in order to catch the parent array path
+// first (in case of arrays inside arrays)
+var arrayRegex = regexp.MustCompile("(.*?)\\[(\\d+)\\]\\.*(.*)")
+
 type arrayTag struct {
 	arrayPath  string
 	arrayIndex int
 	childPath  string
 }
 
+// ConflictError is returned by Insert when two different values are set at the
+// same path, or at a path inside another value.
+type ConflictError struct {
+	Path string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("error: conflicting values for path %s", e.Path)
+}
+
 func NewTree() Tree {
 	return Tree{
 		NodeMap: make(map[string]Node),
+		index:   &pathIndex{},
+	}
+}
+
+// FromMap builds a tree from values keyed by hummus paths. Paths are inserted
+// in sorted order so that conflicts are reported the same way on every call.
+func FromMap(m map[string]interface{}) (Tree, error) {
+	t := NewTree()
+
+	var paths []string
+	for path := range m {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if path == "" {
+			return Tree{}, errors.New("error: empty path")
+		}
+
+		err := t.Insert(fmt.Sprintf("hummus:%q", path), m[path], false)
+		if err != nil {
+			return Tree{}, err
+		}
 	}
+
+	return t, nil
 }
 
 func (t Tree) Insert(tag string, child interface{}, empty bool) error {
-	gt, err := parseHummusTag(reflect.StructTag(tag))
-	if err != nil && strings.Contains(err.Error(), "invalid struct tag") {
+	structTag := reflect.StructTag(tag)
+	if structTag.Get("hummus") == "" {
 		return nil
-	} else if err != nil {
+	}
+
+	gt, err := ParseTag(structTag)
+	if err != nil {
 		return err
 	}
 
-	if gt.omitEmpty && empty {
+	if gt.Has("omitempty") && empty {
 		return nil
 	}
 
-	childToInsert := child
+	return t.insert(gt.Path, child)
+}
 
-	if at, yes := parseArrayTag(gt.tagName); yes {
-		if _, exists := t.NodeMap[at.arrayPath]; !exists {
-			t.NodeMap[at.arrayPath] = Node{
-				Path:          at.arrayPath,
-				IsArray:       true,
-				ArrayChildren: []interface{}{},
+// Delete removes the value at path, along with everything inside it. Array
+// elements are set to null, unless they are at the end of the array, which is
+// shortened instead. It reports whether anything was removed.
+func (t Tree) Delete(path string) bool {
+	at, isArray := parseArrayTag(path)
+	if !isArray {
+		deleted := false
+		for _, p := range t.overlapping(path) {
+			if p == path || strings.HasPrefix(p, path+".") {
+				t.deleteNode(p)
+				deleted = true
 			}
 		}
 
-		node := t.NodeMap[at.arrayPath]
-		if len(node.ArrayChildren) <= at.arrayIndex {
-			arrayChildrenCopy := make([]interface{}, at.arrayIndex+1)
-			for i, c := range node.ArrayChildren {
-				arrayChildrenCopy[i] = c
-			}
-			node.ArrayChildren = arrayChildrenCopy
-		}
+		return deleted
+	}
 
-		if at.childPath != "" {
-			childToInsert = node.ArrayChildren[at.arrayIndex]
-			if childToInsert == nil {
-				childToInsert = NewTree()
-			}
+	node, exists := t.NodeMap[at.arrayPath]
+	if !exists || !node.IsArray || at.arrayIndex >= len(node.ArrayChildren) {
+		return false
+	}
 
-			childTree, ok := childToInsert.(Tree)
-			if !ok {
-				return errors.New("fatal error: existing subchild is not a tree")
-			}
+	if at.childPath != "" {
+		childTree, ok := node.ArrayChildren[at.arrayIndex].(Tree)
+		return ok && childTree.Delete(at.childPath)
+	}
+
+	node.ArrayChildren[at.arrayIndex] = nil
+	for len(node.ArrayChildren) > 0 && node.ArrayChildren[len(node.ArrayChildren)-1] == nil {
+		node.ArrayChildren = node.ArrayChildren[:len(node.ArrayChildren)-1]
+	}
+
+	t.setNode(node)
+	return true
+}
+
+// insert deep-merges child into the tree. Objects and arrays are broken down
+// into their leaves first, so values set through nested structs, slices of
+// structs and flat paths all end up in the same nodes, whatever order they are
+// inserted in. Where two values meet, an empty value gives way to a non-empty
+// one (the deeper one wins if both are empty), and two different non-empty
+// values are a conflict.
+func (t Tree) insert(path string, child interface{}) error {
+	if leaves, ok := splitValue(path, child); ok {
+		var leafPaths []string
+		for leafPath := range leaves {
+			leafPaths = append(leafPaths, leafPath)
+		}
+		sort.Strings(leafPaths)
 
-			err = childTree.Insert(fmt.Sprintf("hummus:%q", at.childPath), child, empty)
-			if err != nil {
+		for _, leafPath := range leafPaths {
+			if err := t.insert(leafPath, leaves[leafPath]); err != nil {
 				return err
 			}
 		}
 
-		node.ArrayChildren[at.arrayIndex] = childToInsert
-		t.NodeMap[at.arrayPath] = node
+		return nil
+	}
+
+	at, isArray := parseArrayTag(path)
+	if !isArray {
+		if ok, conflict := t.makeRoom(path, false, child); conflict {
+			return &ConflictError{Path: path}
+		} else if ok {
+			t.setNode(Node{
+				Path:        path,
+				IsArray:     false,
+				SingleChild: child,
+			})
+		}
+
+		return nil
+	}
+
+	if ok, conflict := t.makeRoom(at.arrayPath, true, child); conflict {
+		return &ConflictError{Path: path}
+	} else if !ok {
+		return nil
+	}
+
+	node, exists := t.NodeMap[at.arrayPath]
+	if !exists {
+		node = Node{
+			Path:          at.arrayPath,
+			IsArray:       true,
+			ArrayChildren: []interface{}{},
+		}
+	}
+
+	for len(node.ArrayChildren) <= at.arrayIndex {
+		node.ArrayChildren = append(node.ArrayChildren, nil)
+	}
+
+	existing := node.ArrayChildren[at.arrayIndex]
+	childTree, existingIsTree := existing.(Tree)
+
+	if at.childPath == "" {
+		switch {
+		case existingIsTree || (!isEmptyValue(existing) && !reflect.DeepEqual(existing, child)):
+			if !isEmptyValue(child) {
+				return &ConflictError{Path: path}
+			}
+		default:
+			node.ArrayChildren[at.arrayIndex] = child
+		}
 	} else {
-		t.NodeMap[gt.tagName] = Node{
-			Path:        gt.tagName,
-			IsArray:     false,
-			SingleChild: child,
+		if !existingIsTree {
+			if !isEmptyValue(existing) {
+				if !isEmptyValue(child) {
+					return &ConflictError{Path: path}
+				}
+				return nil
+			}
+			childTree = Tree{NodeMap: make(map[string]Node)}
+		} else if childTree.index == nil && len(childTree.NodeMap) >= indexThreshold {
+			childTree.index = &pathIndex{}
 		}
+
+		err := childTree.insert(at.childPath, child)
+		if _, ok := err.(*ConflictError); ok {
+			return &ConflictError{Path: path}
+		} else if err != nil {
+			return err
+		}
+
+		node.ArrayChildren[at.arrayIndex] = childTree
 	}
 
+	t.setNode(node)
 	return nil
 }
 
+// makeRoom checks a value about to be set at path against the nodes it
+// overlaps with, i.e. nodes at, inside or around path. Empty nodes that give
+// way to it are removed. It returns false if the value should be dropped
+// instead, and conflict if neither value gives way.
+func (t Tree) makeRoom(path string, isArray bool, child interface{}) (ok bool, conflict bool) {
+	empty := isEmptyValue(child)
+	var giveWay []string
+
+	for _, p := range t.overlapping(path) {
+		node := t.NodeMap[p]
+		if isArray && node.IsArray && p == path {
+			continue
+		}
+
+		switch {
+		case !isArray && !node.IsArray && p == path && reflect.DeepEqual(node.SingleChild, child):
+			return false, false
+		case isEmptyNode(node) && (!empty || len(path) > len(p) || (isArray && p == path)):
+			giveWay = append(giveWay, p)
+		case empty:
+			return false, false
+		default:
+			return false, true
+		}
+	}
+
+	for _, p := range giveWay {
+		t.deleteNode(p)
+	}
+
+	return true, false
+}
+
+// overlapping returns the paths of the nodes at, inside or around path
+func (t Tree) overlapping(path string) []string {
+	var paths []string
+
+	if !t.indexed() {
+		for p := range t.NodeMap {
+			if overlaps(path, p) {
+				paths = append(paths, p)
+			}
+		}
+
+		return paths
+	}
+
+	if _, exists := t.NodeMap[path]; exists {
+		paths = append(paths, path)
+	}
+	for i := range path {
+		if path[i] != '.' {
+			continue
+		}
+
+		if _, exists := t.NodeMap[path[:i]]; exists {
+			paths = append(paths, path[:i])
+		}
+	}
+	for p := range t.index.under[path] {
+		paths = append(paths, p)
+	}
+
+	return paths
+}
+
+// setNode stores node at its path
+func (t Tree) setNode(node Node) {
+	if _, exists := t.NodeMap[node.Path]; !exists && t.indexed() {
+		t.index.add(node.Path)
+	}
+	t.NodeMap[node.Path] = node
+}
+
+// deleteNode removes the node at path
+func (t Tree) deleteNode(path string) {
+	if _, exists := t.NodeMap[path]; exists && t.indexed() {
+		t.index.remove(path)
+	}
+	delete(t.NodeMap, path)
+}
+
+// indexed reports whether the tree has an index, and rebuilds it if it is out
+// of date, e.g. because NodeMap was changed directly
+func (t Tree) indexed() bool {
+	if t.index == nil {
+		return false
+	}
+
+	if t.index.under == nil || t.index.count != len(t.NodeMap) {
+		t.index.count = 0
+		t.index.under = make(map[string]map[string]bool)
+		for p := range t.NodeMap {
+			t.index.add(p)
+		}
+	}
+
+	return true
+}
+
+// add adds a node path to the index, under every path it is inside of
+func (index *pathIndex) add(path string) {
+	index.count++
+
+	for i := range path {
+		if path[i] != '.' {
+			continue
+		}
+
+		if index.under[path[:i]] == nil {
+			index.under[path[:i]] = make(map[string]bool)
+		}
+		index.under[path[:i]][path] = true
+	}
+}
+
+// remove removes a node path from the index
+func (index *pathIndex) remove(path string) {
+	index.count--
+
+	for i := range path {
+		if path[i] != '.' {
+			continue
+		}
+
+		delete(index.under[path[:i]], path)
+		if len(index.under[path[:i]]) == 0 {
+			delete(index.under, path[:i])
+		}
+	}
+}
+
+// splitValue breaks objects and arrays down into values keyed by the paths of
+// their members. Empty objects and arrays, arrays nested directly inside
+// arrays and objects with keys that can't be written as paths aren't split.
+func splitValue(path string, child interface{}) (map[string]interface{}, bool) {
+	leaves := make(map[string]interface{})
+
+	switch value := child.(type) {
+	case map[string]interface{}:
+		for key, member := range value {
+			if key == "" || strings.ContainsAny(key, "#[],") {
+				return nil, false
+			}
+			leaves[path+"."+strings.Replace(key, ".", "#", -1)] = member
+		}
+	case []interface{}:
+		if strings.HasSuffix(path, "]") {
+			return nil, false
+		}
+		for i, member := range value {
+			leaves[fmt.Sprintf("%s[%d]", path, i)] = member
+		}
+	}
+
+	return leaves, len(leaves) > 0
+}
+
+// overlaps reports whether two node paths are the same or one is nested
+// inside the other
+func overlaps(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+}
+
+func isEmptyNode(node Node) bool {
+	return !node.IsArray && isEmptyValue(node.SingleChild)
+}
+
 func (t Tree) BuildJSON() *gabs.Container {
 	jsonObj := gabs.New()
 
-	for path, node := range t.NodeMap {
+	var paths []string
+	for path := range t.NodeMap {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		node := t.NodeMap[path]
 		if !node.IsArray {
 			jsonObj.SetP(node.SingleChild, path)
 		} else {
@@ -165,35 +481,8 @@ func mergeObjects(dst interface{}, src interface{}) interface{} {
 	return dst
 }
 
-func parseHummusTag(tag reflect.StructTag) (hummusTag, error) {
-	hummusTagString := tag.Get("hummus")
-	if hummusTagString == "" {
-		return hummusTag{}, fmt.Errorf("error: invalid struct tag %s", tag)
-	}
-
-	tagFields := strings.Split(hummusTagString, ",")
-	var omitEmpty bool
-
-	if len(tagFields) > 2 {
-		return hummusTag{}, errors.New("error: invalid number of struct tag fields")
-	}
-
-	if len(tagFields) == 2 && tagFields[1] == "omitempty" {
-		omitEmpty = true
-	}
-
-	return hummusTag{
-		tagName:   tagFields[0],
-		omitEmpty: omitEmpty,
-	}, nil
-}
-
 func parseArrayTag(tag string) (arrayTag, bool) {
 	if strings.Contains(tag, "[") {
-		//regex needs to be non-greedy in order to catch the parent array path first
-		//(in case of arrays inside arrays)
-		arrayRegex := regexp.MustCompile("(.*?)\\[(\\d+)\\]\\.*(.*)")
-
 		matches := arrayRegex.FindStringSubmatch(tag)
 		if len(matches) != 4 {
 			return arrayTag{}, false
@@ -212,3 +501,25 @@ func parseArrayTag(tag string) (arrayTag, bool) {
 	}
 	return arrayTag{}, false
 }
+
+// straight-up stole this from encoding/json
+func isEmptyValue(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
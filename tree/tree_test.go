@@ -1,6 +1,8 @@
 package tree_test
 
 import (
+	"fmt"
+
 	"github.com/aditya87/hummus/tree"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -147,6 +149,231 @@ var _ = Describe("Tree", func() {
 				}))
 			})
 		})
+		Context("when provided an object over existing nested paths", func() {
+			It("deep-merges the object with the nested paths", func() {
+				t := tree.NewTree()
+				t.Insert(`hummus:"brand.location"`, "washington", false)
+				err := t.Insert(`hummus:"brand"`, map[string]interface{}{"name": "sabra"}, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(t.NodeMap).To(Equal(map[string]tree.Node{
+					"brand.name": tree.Node{
+						Path:        "brand.name",
+						IsArray:     false,
+						SingleChild: "sabra",
+					},
+					"brand.location": tree.Node{
+						Path:        "brand.location",
+						IsArray:     false,
+						SingleChild: "washington",
+					},
+				}))
+			})
+		})
+
+		Context("when provided an array over existing index paths", func() {
+			It("merges the array with the index paths by index", func() {
+				t := tree.NewTree()
+				t.Insert(`hummus:"brands[1].location"`, "washington", false)
+				err := t.Insert(`hummus:"brands"`, []interface{}{
+					map[string]interface{}{"name": "sabra"},
+					map[string]interface{}{"name": "cedars"},
+				}, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect([]byte(t.BuildJSON().String())).To(MatchJSON(`{
+					"brands": [
+						{
+							"name": "sabra"
+						},
+						{
+							"name": "cedars",
+							"location": "washington"
+						}
+					]
+				}`))
+			})
+		})
+
+		Context("when provided the same value twice", func() {
+			It("keeps the value", func() {
+				t := tree.NewTree()
+				t.Insert(`hummus:"brand.name"`, "sabra", false)
+				err := t.Insert(`hummus:"brand"`, map[string]interface{}{"name": "sabra"}, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(t.NodeMap).To(HaveLen(1))
+			})
+		})
+
+		Context("when provided an empty and a non-empty value for the same path", func() {
+			It("keeps the non-empty value whatever the order", func() {
+				t := tree.NewTree()
+				t.Insert(`hummus:"brand.name"`, "sabra", false)
+				t.Insert(`hummus:"brand"`, map[string]interface{}{"name": ""}, false)
+				t.Insert(`hummus:"brands[0]"`, "", false)
+				t.Insert(`hummus:"brands[0]"`, "cedars", false)
+				Expect([]byte(t.BuildJSON().String())).To(MatchJSON(`{
+					"brand": {
+						"name": "sabra"
+					},
+					"brands": ["cedars"]
+				}`))
+			})
+		})
+
+		Context("when provided an empty value over existing nested paths", func() {
+			It("keeps the nested paths", func() {
+				t := tree.NewTree()
+				t.Insert(`hummus:"brand.stores[0]"`, "safeway", false)
+				err := t.Insert(`hummus:"brand"`, "", false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect([]byte(t.BuildJSON().String())).To(MatchJSON(`{
+					"brand": {
+						"stores": ["safeway"]
+					}
+				}`))
+			})
+		})
+
+		Context("when provided two different values for the same path", func() {
+			It("returns a conflict error", func() {
+				t := tree.NewTree()
+				t.Insert(`hummus:"brand.name"`, "cedars", false)
+				err := t.Insert(`hummus:"brand"`, map[string]interface{}{"name": "sabra"}, false)
+				Expect(err).To(MatchError("error: conflicting values for path brand.name"))
+				Expect(err).To(BeAssignableToTypeOf(&tree.ConflictError{}))
+			})
+		})
+
+		Context("when provided a value at a path inside another value", func() {
+			It("returns a conflict error", func() {
+				t := tree.NewTree()
+				t.Insert(`hummus:"brand.name"`, "cedars", false)
+				err := t.Insert(`hummus:"brand"`, "sabra", false)
+				Expect(err).To(MatchError("error: conflicting values for path brand"))
+
+				err = t.Insert(`hummus:"brand[0]"`, "sabra", false)
+				Expect(err).To(MatchError("error: conflicting values for path brand[0]"))
+
+				t.Insert(`hummus:"stores[0].name"`, "safeway", false)
+				err = t.Insert(`hummus:"stores[0]"`, "wholefoods", false)
+				Expect(err).To(MatchError("error: conflicting values for path stores[0]"))
+			})
+		})
+
+		Context("when array elements grow large", func() {
+			It("still finds the values paths are inside of", func() {
+				t := tree.NewTree()
+				for i := 0; i < 100; i++ {
+					Expect(t.Insert(fmt.Sprintf(`hummus:"brands[0].stores.store%d.name"`, i), "safeway", false)).To(Succeed())
+				}
+
+				err := t.Insert(`hummus:"brands[0].stores.store50"`, "wholefoods", false)
+				Expect(err).To(MatchError("error: conflicting values for path brands[0].stores.store50"))
+				Expect(t.Insert(`hummus:"brands[0].stores"`, map[string]interface{}{}, false)).To(Succeed())
+				Expect(t.Delete("brands[0].stores")).To(BeTrue())
+				Expect(t.BuildJSON().String()).To(MatchJSON(`{"brands": [{}]}`))
+			})
+		})
+
+		Context("when nodes were added to NodeMap directly", func() {
+			It("still finds the values paths are inside of", func() {
+				t := tree.NewTree()
+				t.Insert(`hummus:"company"`, "hello foods", false)
+				t.NodeMap["brand.name"] = tree.Node{Path: "brand.name", SingleChild: "sabra"}
+
+				err := t.Insert(`hummus:"brand"`, "cedars", false)
+				Expect(err).To(MatchError("error: conflicting values for path brand"))
+			})
+		})
+	})
+
+	Describe("FromMap", func() {
+		Context("when provided a map of paths", func() {
+			It("inserts every path into the tree", func() {
+				t, err := tree.FromMap(map[string]interface{}{
+					"brand":     "sabra",
+					"brands[1]": "cedars",
+					"brands[0]": "sabra",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(t.NodeMap).To(Equal(map[string]tree.Node{
+					"brand": tree.Node{
+						Path:        "brand",
+						IsArray:     false,
+						SingleChild: "sabra",
+					},
+					"brands": tree.Node{
+						Path:          "brands",
+						IsArray:       true,
+						ArrayChildren: []interface{}{"sabra", "cedars"},
+					},
+				}))
+			})
+		})
+
+		Context("when a path starts with a slash", func() {
+			It("takes it as a key", func() {
+				t, err := tree.FromMap(map[string]interface{}{
+					"/api.v": 1,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(t.BuildJSON().String()).To(MatchJSON(`{"/api": {"v": 1}}`))
+			})
+		})
+
+		Context("when provided an empty path", func() {
+			It("returns an error", func() {
+				_, err := tree.FromMap(map[string]interface{}{
+					"": "sabra",
+				})
+				Expect(err).To(MatchError("error: empty path"))
+			})
+		})
+	})
+
+	Describe("Delete", func() {
+		var t tree.Tree
+
+		BeforeEach(func() {
+			var err error
+			t, err = tree.FromMap(map[string]interface{}{
+				"brand.name":           "sabra",
+				"brand.flavor":         "jalapeno",
+				"brand.stores[0].name": "safeway",
+				"brand.stores[1].name": "wholefoods",
+				"brand.stores[1].city": "sf",
+				"brand.stores[2].name": "costco",
+				"company":              "hello foods",
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("removes values and everything inside them", func() {
+			Expect(t.Delete("brand.flavor")).To(BeTrue())
+			Expect(t.Delete("brand.stores[1].city")).To(BeTrue())
+			Expect(t.Delete("brand.stores[0]")).To(BeTrue())
+			Expect(t.BuildJSON().Bytes()).To(MatchJSON(`{
+				"brand": {
+					"name": "sabra",
+					"stores": [null, {"name": "wholefoods"}, {"name": "costco"}]
+				},
+				"company": "hello foods"
+			}`))
+
+			Expect(t.Delete("brand")).To(BeTrue())
+			Expect(t.BuildJSON().Bytes()).To(MatchJSON(`{"company": "hello foods"}`))
+		})
+
+		It("shortens arrays when their last elements are removed", func() {
+			Expect(t.Delete("brand.stores[2]")).To(BeTrue())
+			Expect(t.Delete("brand.stores[1]")).To(BeTrue())
+			Expect(t.BuildJSON().Path("brand.stores").Bytes()).To(MatchJSON(`[{"name": "safeway"}]`))
+		})
+
+		It("reports when there is nothing to remove", func() {
+			Expect(t.Delete("brand.missing")).To(BeFalse())
+			Expect(t.Delete("brand.stores[5]")).To(BeFalse())
+			Expect(t.Delete("brand.stores[0].missing")).To(BeFalse())
+		})
 	})
 
 	Describe("BuildJSON", func() {
@@ -0,0 +1,235 @@
+package hummus
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var tsIdentifierRegex = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+type tsWriter struct {
+	queue   []reflect.Type
+	emitted map[reflect.Type]bool
+	tags    Tags
+}
+
+// TypeScript generates TypeScript interfaces describing the nested documents
+// that Marshal produces from values of the given types. Named structs found in
+// slices get interfaces of their own.
+func TypeScript(types ...reflect.Type) (string, error) {
+	return Tags{}.TypeScript(types...)
+}
+
+// TypeScript is like the package-level TypeScript, but lays out the chosen
+// tags
+func (tags Tags) TypeScript(types ...reflect.Type) (string, error) {
+	w := &tsWriter{emitted: make(map[reflect.Type]bool), tags: tags}
+	for _, t := range types {
+		if t.Kind() != reflect.Struct || t.Name() == "" {
+			return "", fmt.Errorf("error: can only generate TypeScript for named structs, got %s", t)
+		}
+		w.enqueue(t)
+	}
+
+	var buf bytes.Buffer
+	for len(w.queue) > 0 {
+		t := w.queue[0]
+		w.queue = w.queue[1:]
+
+		body, err := w.structType(t, 0)
+		if err != nil {
+			return "", err
+		}
+
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "export interface %s %s\n", t.Name(), body)
+	}
+
+	return buf.String(), nil
+}
+
+func (w *tsWriter) enqueue(t reflect.Type) {
+	if !w.emitted[t] {
+		w.emitted[t] = true
+		w.queue = append(w.queue, t)
+	}
+}
+
+func (w *tsWriter) structType(t reflect.Type, depth int) (string, error) {
+	layout, err := layoutFields(t, map[reflect.Type]bool{}, w.tags)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := layout.(map[string]interface{}); !ok {
+		return "{}", nil
+	}
+
+	return w.documentType(layout, depth)
+}
+
+// documentType renders the type of a document laid out by layoutFields
+func (w *tsWriter) documentType(doc interface{}, depth int) (string, error) {
+	switch value := doc.(type) {
+	case *fieldSchema:
+		if value.kind == structSliceField {
+			return w.structSliceType(value.typ, depth)
+		}
+		return w.goType(value.typ, depth)
+	case map[string]interface{}:
+		var keys []string
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var buf bytes.Buffer
+		buf.WriteString("{\n")
+		for _, key := range keys {
+			memberType, err := w.documentType(value[key], depth+1)
+			if err != nil {
+				return "", err
+			}
+
+			optional := "?"
+			if _, required := documentSchema(value[key]); required {
+				optional = ""
+			}
+
+			fmt.Fprintf(&buf, "%s%s%s: %s;\n", tsIndent(depth+1), tsPropertyName(key), optional, memberType)
+		}
+		buf.WriteString(tsIndent(depth) + "}")
+
+		return buf.String(), nil
+	case []interface{}:
+		minItems := 0
+		for i, element := range value {
+			if _, required := documentSchema(element); element != nil && required {
+				minItems = i + 1
+			}
+		}
+
+		var elements []string
+		for i, element := range value {
+			elementType := "null"
+			if element != nil {
+				var err error
+				elementType, err = w.documentType(element, depth)
+				if err != nil {
+					return "", err
+				}
+			}
+
+			_, required := documentSchema(element)
+			switch {
+			case i >= minItems:
+				elementType += "?"
+			case element != nil && !required:
+				elementType += " | null"
+			}
+
+			elements = append(elements, elementType)
+		}
+
+		return "[" + strings.Join(elements, ", ") + "]", nil
+	}
+
+	return "unknown", nil
+}
+
+func (w *tsWriter) goType(t reflect.Type, depth int) (string, error) {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number", nil
+	case reflect.String:
+		return "string", nil
+	case reflect.Slice, reflect.Array:
+		// nil slices are written as null
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return "string | null", nil
+		}
+
+		elem, err := w.jsonType(t.Elem(), depth)
+		if err != nil {
+			return "", err
+		}
+
+		if strings.Contains(elem, " | ") {
+			elem = "(" + elem + ")"
+		}
+		if t.Kind() == reflect.Array {
+			return elem + "[]", nil
+		}
+		return elem + "[] | null", nil
+	case reflect.Map:
+		elem, err := w.jsonType(t.Elem(), depth)
+		if err != nil {
+			return "", err
+		}
+
+		// nil maps are written as null
+		return "{ [key: string]: " + elem + " } | null", nil
+	case reflect.Ptr:
+		elem, err := w.jsonType(t.Elem(), depth)
+		if err != nil {
+			return "", err
+		}
+
+		if strings.HasSuffix(elem, " | null") {
+			return elem, nil
+		}
+		return elem + " | null", nil
+	case reflect.Interface:
+		return "unknown", nil
+	}
+
+	return "", fmt.Errorf("error: cannot generate TypeScript for kind %s", t.Kind())
+}
+
+// structSliceType renders a slice of structs marshaled by hummus
+func (w *tsWriter) structSliceType(t reflect.Type, depth int) (string, error) {
+	if t.Elem().Name() != "" {
+		w.enqueue(t.Elem())
+		return t.Elem().Name() + "[] | null", nil
+	}
+
+	elem, err := w.structType(t.Elem(), depth)
+	if err != nil {
+		return "", err
+	}
+
+	return elem + "[] | null", nil
+}
+
+// jsonType is goType for values that are handed straight to encoding/json,
+// which doesn't know about hummus tags
+func (w *tsWriter) jsonType(t reflect.Type, depth int) (string, error) {
+	if t.Kind() == reflect.Struct {
+		return "{ [key: string]: unknown }", nil
+	}
+
+	return w.goType(t, depth)
+}
+
+func tsPropertyName(key string) string {
+	if tsIdentifierRegex.MatchString(key) {
+		return key
+	}
+
+	return strconv.Quote(key)
+}
+
+func tsIndent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
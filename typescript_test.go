@@ -0,0 +1,86 @@
+package hummus_test
+
+import (
+	"reflect"
+
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type TSStore struct {
+	Name  string  `hummus:"name"`
+	Price float64 `hummus:"price,omitempty"`
+}
+
+type TSInfo struct {
+	Company    string         `hummus:"company"`
+	Brand0Name string         `hummus:"brands[0].name"`
+	Brand1Name string         `hummus:"brands[1].name,omitempty"`
+	Escaped    bool           `hummus:"properties#name"`
+	Stores     []TSStore      `hummus:"reputation.stores"`
+	Tags       []*string      `hummus:"reputation.tags,omitempty"`
+	Attributes map[string]int `hummus:"attributes"`
+	Untagged   string
+}
+
+var _ = Describe("TypeScript", func() {
+	It("generates interfaces for the nested document", func() {
+		ts, err := hummus.TypeScript(reflect.TypeOf(TSInfo{}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ts).To(Equal(`export interface TSInfo {
+  attributes: { [key: string]: number } | null;
+  brands: [{
+    name: string;
+  }, {
+    name?: string;
+  }?];
+  company: string;
+  "properties.name": boolean;
+  reputation: {
+    stores: TSStore[] | null;
+    tags?: (string | null)[] | null;
+  };
+}
+
+export interface TSStore {
+  name: string;
+  price?: number;
+}
+`))
+	})
+
+	It("allows optional elements before required ones to be null", func() {
+		type Info struct {
+			Brand0 string `hummus:"brands[0],omitempty"`
+			Brand2 string `hummus:"brands[2]"`
+		}
+
+		ts, err := hummus.TypeScript(reflect.TypeOf(Info{}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ts).To(Equal(`export interface Info {
+  brands: [string | null, null, string];
+}
+`))
+	})
+
+	Context("special/failure cases", func() {
+		Context("when passed a type that isn't a named struct", func() {
+			It("returns an error", func() {
+				_, err := hummus.TypeScript(reflect.TypeOf(struct{}{}))
+				Expect(err).To(MatchError("error: can only generate TypeScript for named structs, got struct {}"))
+			})
+		})
+
+		Context("when a field has an unsupported kind", func() {
+			It("returns an error", func() {
+				type Info struct {
+					C chan int `hummus:"channel"`
+				}
+
+				_, err := hummus.TypeScript(reflect.TypeOf(Info{}))
+				Expect(err).To(MatchError("error: cannot generate a schema for kind chan"))
+			})
+		})
+	})
+})
@@ -0,0 +1,137 @@
+package hummus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Unmarshal is the reverse of Marshal: it stores the values found at the paths
+// of the hummus tags of the struct v points to in the tagged fields. Fields
+// whose paths are missing from data are left alone.
+func Unmarshal(data []byte, v interface{}) error {
+	return Tags{}.Unmarshal(data, v)
+}
+
+// Unmarshal is like the package-level Unmarshal, but reads the chosen tags
+func (tags Tags) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("error: can only unmarshal into a pointer to a struct")
+	}
+
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return err
+	}
+
+	return unmarshalReflect(doc, rv.Elem(), tags)
+}
+
+func unmarshalReflect(doc interface{}, v reflect.Value, tags Tags) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, ht, err := tags.parseField(field)
+		if err != nil && tag.Get("hummus") == "" {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if field.PkgPath != "" || ht.isComputed() {
+			continue
+		}
+
+		value, found := lookupPath(doc, ht.tagName)
+
+		// nested structs are looked up under their path even when it is
+		// missing, since they can be merged with flat paths
+		if ht.kind(field.Type) == nestedField {
+			if err := unmarshalReflect(value, v.Field(i), tags); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := unmarshalField(field, ht, value, found, v.Field(i), tags); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unmarshalField stores the value found at the path of a field in the field,
+// which is left alone (or set to its default) if the path wasn't found
+func unmarshalField(field reflect.StructField, ht hummusTag, value interface{}, found bool, v reflect.Value, tags Tags) error {
+	if !found || value == nil {
+		switch {
+		case ht.hasDefault:
+			def, err := defaultValue(field, ht.defaultValue)
+			if err != nil {
+				return err
+			}
+			v.Set(def)
+		case ht.required:
+			return fmt.Errorf("error: required field %s (path %s) is missing", field.Name, ht.tagName)
+		}
+		return nil
+	}
+
+	if ht.isFormatted() {
+		if err := unformatValue(ht, value, v); err != nil {
+			return fmt.Errorf("error: field %s (path %s): %s", field.Name, ht.tagName, errorText(err))
+		}
+	} else if ht.asString && quotable(field.Type) {
+		quoted, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("error: field %s (path %s): expected a quoted value, got %v", field.Name, ht.tagName, value)
+		}
+
+		if err := json.Unmarshal([]byte(quoted), v.Addr().Interface()); err != nil {
+			return fmt.Errorf("error: field %s (path %s): %s", field.Name, ht.tagName, errorText(err))
+		}
+	} else if ht.kind(field.Type) == structSliceField {
+		if err := unmarshalStructSlice(value, v, tags); err != nil {
+			return fmt.Errorf("error: field %s (path %s): %s", field.Name, ht.tagName, errorText(err))
+		}
+	} else if err := unmarshalValue(value, v); err != nil {
+		return fmt.Errorf("error: field %s (path %s): %s", field.Name, ht.tagName, errorText(err))
+	}
+
+	if ht.required && isEmptyValue(v) {
+		return fmt.Errorf("error: required field %s (path %s) is empty", field.Name, ht.tagName)
+	}
+
+	return nil
+}
+
+func unmarshalStructSlice(value interface{}, v reflect.Value, tags Tags) error {
+	elements, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("error: cannot unmarshal %T into %s", value, v.Type())
+	}
+
+	slice := reflect.MakeSlice(v.Type(), len(elements), len(elements))
+	for i, element := range elements {
+		if err := unmarshalReflect(element, slice.Index(i), tags); err != nil {
+			return err
+		}
+	}
+
+	v.Set(slice)
+	return nil
+}
+
+func unmarshalValue(value interface{}, v reflect.Value) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v.Addr().Interface())
+}
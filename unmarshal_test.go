@@ -0,0 +1,137 @@
+package hummus_test
+
+import (
+	"github.com/aditya87/hummus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type UnmarshalStore struct {
+	Name  string  `hummus:"name"`
+	Price float64 `hummus:"price"`
+}
+
+type UnmarshalBrand struct {
+	Name   string `hummus:"name"`
+	Flavor string `hummus:"flavor"`
+}
+
+type UnmarshalInfo struct {
+	Company    string           `hummus:"company"`
+	Brand      UnmarshalBrand   `hummus:"brand"`
+	Store0Name string           `hummus:"brands[0].stores[0].name"`
+	Escaped    bool             `hummus:"properties#name"`
+	Stores     []UnmarshalStore `hummus:"stores"`
+	ID         int64            `hummus:"order.id"`
+	Tags       []string         `hummus:"tags"`
+	Untagged   string
+}
+
+var _ = Describe("Unmarshal", func() {
+	It("is the reverse of Marshal", func() {
+		input := UnmarshalInfo{
+			Company:    "hello foods",
+			Brand:      UnmarshalBrand{Name: "sabra", Flavor: "jalapeno"},
+			Store0Name: "safeway",
+			Escaped:    true,
+			Stores:     []UnmarshalStore{{Name: "safeway", Price: 5}, {Name: "wholefoods", Price: 10.5}},
+			ID:         9007199254740993,
+			Tags:       []string{"spicy"},
+		}
+
+		outJSON, err := hummus.Marshal(input)
+		Expect(err).NotTo(HaveOccurred())
+
+		var output UnmarshalInfo
+		Expect(hummus.Unmarshal(outJSON, &output)).To(Succeed())
+		Expect(output).To(Equal(input))
+	})
+
+	It("leaves fields whose paths are missing alone", func() {
+		output := UnmarshalInfo{Company: "hello foods", Untagged: "kept"}
+		Expect(hummus.Unmarshal([]byte(`{"brand": {"name": "sabra"}}`), &output)).To(Succeed())
+		Expect(output).To(Equal(UnmarshalInfo{
+			Company:  "hello foods",
+			Brand:    UnmarshalBrand{Name: "sabra"},
+			Untagged: "kept",
+		}))
+	})
+
+	It("fills in defaults for missing paths", func() {
+		var output struct {
+			Currency string `hummus:"order.currency,default=USD"`
+			Quantity int    `hummus:"order.quantity,default=1"`
+		}
+
+		Expect(hummus.Unmarshal([]byte(`{"order": {"quantity": 3}}`), &output)).To(Succeed())
+		Expect(output.Currency).To(Equal("USD"))
+		Expect(output.Quantity).To(Equal(3))
+	})
+
+	It("parses quoted numbers and booleans with the string option", func() {
+		var output struct {
+			ID      int64   `hummus:"order.id,string"`
+			Amount  float64 `hummus:"order.amount,string"`
+			Express bool    `hummus:"order.express,string"`
+			Ref     *int64  `hummus:"order.ref,string"`
+		}
+
+		err := hummus.Unmarshal([]byte(`{"order": {"id": "9007199254740993", "amount": "9.99", "express": "true", "ref": "42"}}`), &output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.ID).To(Equal(int64(9007199254740993)))
+		Expect(output.Amount).To(Equal(9.99))
+		Expect(output.Express).To(BeTrue())
+		Expect(*output.Ref).To(Equal(int64(42)))
+	})
+
+	Context("special/failure cases", func() {
+		Context("when a required path is missing", func() {
+			It("returns an error naming the field", func() {
+				var output struct {
+					CustomerID string `hummus:"customer.id,required"`
+				}
+
+				err := hummus.Unmarshal([]byte(`{"customer": {}}`), &output)
+				Expect(err).To(MatchError("error: required field CustomerID (path customer.id) is missing"))
+			})
+		})
+
+		Context("when a required value is empty", func() {
+			It("returns an error naming the field", func() {
+				var output struct {
+					CustomerID string `hummus:"customer.id,required"`
+				}
+
+				err := hummus.Unmarshal([]byte(`{"customer": {"id": ""}}`), &output)
+				Expect(err).To(MatchError("error: required field CustomerID (path customer.id) is empty"))
+			})
+		})
+
+		Context("when a value has the wrong type", func() {
+			It("returns an error naming the field", func() {
+				var output UnmarshalInfo
+				err := hummus.Unmarshal([]byte(`{"order": {"id": "abc"}}`), &output)
+				Expect(err).To(MatchError(ContainSubstring("error: field ID (path order.id): ")))
+			})
+		})
+
+		Context("when a value for the string option isn't quoted", func() {
+			It("returns an error naming the field", func() {
+				var output struct {
+					ID int64 `hummus:"order.id,string"`
+				}
+
+				err := hummus.Unmarshal([]byte(`{"order": {"id": 42}}`), &output)
+				Expect(err).To(MatchError("error: field ID (path order.id): expected a quoted value, got 42"))
+			})
+		})
+
+		Context("when not passed a pointer to a struct", func() {
+			It("returns an error", func() {
+				var output UnmarshalInfo
+				err := hummus.Unmarshal([]byte(`{}`), output)
+				Expect(err).To(MatchError("error: can only unmarshal into a pointer to a struct"))
+			})
+		})
+	})
+})